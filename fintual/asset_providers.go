@@ -29,32 +29,52 @@ type AssetProviderAttributes struct {
 //
 // Endpoint: GET /asset_providers
 func (s *AssetProvidersService) ListAll(ctx context.Context) ([]*AssetProvider, error) {
+	ap, _, err := s.ListAllWithResponse(ctx)
+	return ap, err
+}
+
+// ListAllWithResponse is like ListAll, but it also returns
+// the response of the API.
+func (s *AssetProvidersService) ListAllWithResponse(ctx context.Context) ([]*AssetProvider, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "AssetProviders", "ListAll", "/asset_providers")
+	defer op.end()
+
 	url := s.client.baseURL.String() + assetProvidersEndpoint
 	var ap struct {
 		Data []*AssetProvider `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &ap)
+	resp, err := s.client.get(ctx, url, &ap)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return ap.Data, nil
+	return ap.Data, resp, nil
 }
 
 // Get retrieves a single asset provider.
 //
 // Endpoint: GET /asset_providers/:id
 func (s *AssetProvidersService) Get(ctx context.Context, id string) (*AssetProvider, error) {
+	ap, _, err := s.GetWithResponse(ctx, id)
+	return ap, err
+}
+
+// GetWithResponse is like Get, but it also returns
+// the response of the API.
+func (s *AssetProvidersService) GetWithResponse(ctx context.Context, id string) (*AssetProvider, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "AssetProviders", "Get", "/asset_providers/:id", attr(attrResourceID, id))
+	defer op.end()
+
 	url := fmt.Sprintf("%s/%s", s.client.baseURL.String()+assetProvidersEndpoint, id)
 	var ap struct {
 		Data *AssetProvider `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &ap)
+	resp, err := s.client.get(ctx, url, &ap)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return ap.Data, nil
+	return ap.Data, resp, nil
 }
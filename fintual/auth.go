@@ -3,10 +3,43 @@ package fintual
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/url"
+	"time"
 )
 
 const (
 	accessTokenEndpoint = "/access_tokens"
+
+	userEmailHeader = "X-User-Email"
+	userTokenHeader = "X-User-Token"
+	userEmailParam  = "user_email"
+	userTokenParam  = "user_token"
+
+	redacted = "REDACTED"
+
+	// maxRedirects is the number of redirects followed by
+	// the default policy of net/http.
+	maxRedirects = 10
+
+	// defaultRefreshTimeout bounds a re-authentication shared by
+	// concurrent requests when the client has no timeout.
+	defaultRefreshTimeout = 30 * time.Second
+)
+
+// AuthMethod specifies how authentication credentials
+// are sent to the API.
+type AuthMethod int
+
+const (
+	// AuthHeader sends credentials in the X-User-Email
+	// and X-User-Token headers.
+	AuthHeader AuthMethod = iota
+
+	// AuthQuery sends credentials in the user_email and user_token
+	// URL query parameters. Prefer AuthHeader, since URLs tend to end
+	// up in proxy logs.
+	AuthQuery
 )
 
 type accessToken struct {
@@ -24,8 +57,34 @@ type Credentials struct {
 }
 
 // Authenticate tries to retrieve a user access token from the
-// Fintual access_tokens endpoint and sets it to the current Fintual client
+// Fintual access_tokens endpoint and sets it to the current Fintual client.
+//
+// If the client has a TokenStore holding a session for the given email,
+// that session is used instead and no request is made. Should the API
+// reject the stored session, the store is cleared and the client logs in
+// once with the given password.
 func (c *Client) Authenticate(ctx context.Context, email, password string) error {
+	if c.tokens != nil {
+		s, err := c.tokens.Load(ctx)
+		if err != nil && !errors.Is(err, ErrNoSession) {
+			return err
+		}
+		if err == nil && s.Email == email && s.valid() {
+			c.session.restore(s, Credentials{Email: email, Password: password})
+			return nil
+		}
+	}
+
+	return c.login(ctx, email, password)
+}
+
+// login retrieves a new access token from the Fintual access_tokens
+// endpoint, sets it to the current Fintual client and saves it to
+// the client's TokenStore.
+func (c *Client) login(ctx context.Context, email, password string) error {
+	ctx, op := c.startOperation(ctx, "Auth", "Authenticate", "/access_tokens")
+	defer op.end()
+
 	reqBody := struct {
 		User Credentials `json:"user"`
 	}{User: Credentials{Email: email, Password: password}}
@@ -34,7 +93,7 @@ func (c *Client) Authenticate(ctx context.Context, email, password string) error
 		Data accessToken `json:"data"`
 	}
 	url := c.baseURL.String() + accessTokenEndpoint
-	err := c.post(ctx, url, reqBody, &data)
+	_, err := c.post(ctx, url, reqBody, &data)
 	if err != nil {
 		return err
 	}
@@ -43,7 +102,190 @@ func (c *Client) Authenticate(ctx context.Context, email, password string) error
 		return errors.New("fitual auth failed - didn't get access token")
 	}
 
-	c.setUserEmail(email)
-	c.setAccessToken(data.Data.Attributes.Token)
+	s := Session{Email: email, Token: data.Data.Attributes.Token}
+	c.SetSession(s)
+	if c.tokens != nil {
+		return c.tokens.Save(ctx, s)
+	}
 	return nil
 }
+
+// Logout revokes the current access token and clears the session of the
+// client and of its TokenStore. The session is cleared even if the API
+// fails to revoke the token.
+//
+// Endpoint: DELETE /access_tokens
+func (c *Client) Logout(ctx context.Context) error {
+	ctx, op := c.startOperation(ctx, "Auth", "Logout", "/access_tokens")
+	defer op.end()
+
+	var err error
+	if session := c.Session(); session.valid() {
+		url := c.baseURL.String() + accessTokenEndpoint
+		_, err = c.sendAs(ctx, session, "DELETE", url, nil, nil)
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrNotFound) {
+			// The token is already invalid.
+			err = nil
+		}
+	}
+
+	c.SetSession(Session{})
+	if c.tokens != nil {
+		if cerr := c.tokens.Clear(ctx); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// IsAuthenticated reports whether the client holds a session.
+// It does not check whether the API still accepts the session's token.
+func (c *Client) IsAuthenticated() bool {
+	return c.Session().valid()
+}
+
+// reauthenticate authenticates with the credentials of the client's
+// CredentialsProvider, unless the session has already changed since stale
+// was read. Without a stale session, the client's TokenStore is checked
+// first, as by Authenticate, while a new access token is always requested
+// to replace a stale one. A stale session loaded from the TokenStore by
+// Authenticate is replaced by logging in with the password given to it.
+// Concurrent calls are collapsed into a single request, which
+// is not canceled when the caller which started it leaves. Each caller stops
+// waiting as soon as its own ctx is done.
+func (c *Client) reauthenticate(ctx context.Context, stale Session) error {
+	st := c.session
+
+	st.refreshMu.Lock()
+	if cur := st.load(); cur.valid() && cur != stale {
+		st.refreshMu.Unlock()
+		return nil
+	}
+	call := st.refreshing
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		st.refreshing = call
+		go c.refresh(detachedContext{ctx}, call, stale)
+	}
+	st.refreshMu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refresh makes the re-authentication call, bounded by the
+// client's timeout.
+func (c *Client) refresh(ctx context.Context, call *refreshCall, stale Session) {
+	timeout := c.http.Timeout
+	if timeout <= 0 {
+		timeout = defaultRefreshTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	call.err = c.relogin(ctx, stale)
+
+	st := c.session
+	st.refreshMu.Lock()
+	st.refreshing = nil
+	st.refreshMu.Unlock()
+	close(call.done)
+}
+
+// relogin replaces the stale session, with the credentials of the
+// client's CredentialsProvider or those kept by Authenticate.
+func (c *Client) relogin(ctx context.Context, stale Session) error {
+	if creds, ok := c.session.takeLogin(stale); ok && c.credentials == nil {
+		if c.tokens != nil {
+			if err := c.tokens.Clear(ctx); err != nil {
+				return err
+			}
+		}
+		return c.login(ctx, creds.Email, creds.Password)
+	}
+	if c.credentials == nil {
+		return ErrNotAuthenticated
+	}
+
+	creds, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if stale.valid() {
+		return c.login(ctx, creds.Email, creds.Password)
+	}
+	return c.Authenticate(ctx, creds.Email, creds.Password)
+}
+
+// setAuth adds the given credentials to req, as specified
+// by the client's auth method.
+func (c *Client) setAuth(req *http.Request, email, token string) {
+	if c.authMethod == AuthQuery {
+		q := req.URL.Query()
+		q.Set(userEmailParam, email)
+		q.Set(userTokenParam, token)
+		req.URL.RawQuery = q.Encode()
+		return
+	}
+
+	req.Header.Set(userEmailHeader, email)
+	req.Header.Set(userTokenHeader, token)
+}
+
+// checkRedirect returns a CheckRedirect function for the client's
+// http.Client which drops the credential headers when a request is
+// redirected to another host, since net/http only drops Authorization
+// and Cookie. It then defers to next, or to the default policy of
+// net/http if next is nil.
+func checkRedirect(next func(req *http.Request, via []*http.Request) error) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del(userEmailHeader)
+			req.Header.Del(userTokenHeader)
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+// redactURL returns u as a string, with any credentials
+// in its query replaced.
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	if q.Get(userEmailParam) == "" && q.Get(userTokenParam) == "" {
+		return u.String()
+	}
+
+	for _, k := range []string{userEmailParam, userTokenParam} {
+		if q.Get(k) != "" {
+			q.Set(k, redacted)
+		}
+	}
+	r := *u
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// redactError removes credentials from the URL of err, if it is a *url.Error.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	u, perr := url.Parse(urlErr.URL)
+	if perr != nil {
+		return err
+	}
+	urlErr.URL = redactURL(u)
+	return err
+}
@@ -0,0 +1,364 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authHandler serves goals to requests carrying the token it hands out,
+// and rejects any other token with a 401.
+type authHandler struct {
+	token  string        // Token accepted and handed out on login
+	delay  time.Duration // Time taken by a login
+	logins int32
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == accessTokenEndpoint {
+		atomic.AddInt32(&h.logins, 1)
+		time.Sleep(h.delay)
+		w.Write([]byte(`{"data":{"type":"access_token","attributes":{"token":"` + h.token + `"}}}`))
+		return
+	}
+
+	if r.Header.Get(userTokenHeader) != h.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"invalid token"}`))
+		return
+	}
+	w.Write([]byte(`{"data":[]}`))
+}
+
+var testCredentials = CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+	return Credentials{Email: "user@example.com", Password: "secret"}, nil
+})
+
+func TestSendWithAuth_usesStoredSession(t *testing.T) {
+	h := &authHandler{token: "stored"}
+	store := NewMemoryTokenStore()
+	store.Save(context.Background(), Session{Email: "user@example.com", Token: "stored"})
+	c := setup(t, h, WithTokenStore(store), WithCredentialsProvider(testCredentials))
+
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Fatalf("Goals.ListAll returned error: %v", err)
+	}
+	if n := atomic.LoadInt32(&h.logins); n != 0 {
+		t.Errorf("client logged in %d times, want 0", n)
+	}
+}
+
+func TestSendWithAuth_reauthenticatesOnUnauthorized(t *testing.T) {
+	h := &authHandler{token: "fresh"}
+	store := NewMemoryTokenStore()
+	store.Save(context.Background(), Session{Email: "user@example.com", Token: "expired"})
+	c := setup(t, h, WithTokenStore(store), WithCredentialsProvider(testCredentials))
+
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Fatalf("Goals.ListAll returned error: %v", err)
+	}
+	if n := atomic.LoadInt32(&h.logins); n != 1 {
+		t.Errorf("client logged in %d times, want 1", n)
+	}
+	if s, _ := store.Load(context.Background()); s.Token != "fresh" {
+		t.Errorf("stored token is %q, want %q", s.Token, "fresh")
+	}
+}
+
+func TestAuthenticate_replacesRejectedStoredSession(t *testing.T) {
+	h := &authHandler{token: "fresh"}
+	store := NewMemoryTokenStore()
+	store.Save(context.Background(), Session{Email: "user@example.com", Token: "expired"})
+
+	// Each run stands for a new process authenticating with the same store.
+	for run := 1; run <= 2; run++ {
+		c := setup(t, h, WithTokenStore(store))
+		if err := c.Authenticate(context.Background(), "user@example.com", "secret"); err != nil {
+			t.Fatalf("run %d: Authenticate returned error: %v", run, err)
+		}
+		if _, err := c.Goals.ListAll(context.Background()); err != nil {
+			t.Fatalf("run %d: Goals.ListAll returned error: %v", run, err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&h.logins); n != 1 {
+		t.Errorf("clients logged in %d times, want 1", n)
+	}
+	if s, _ := store.Load(context.Background()); s.Token != "fresh" {
+		t.Errorf("stored token is %q, want %q", s.Token, "fresh")
+	}
+}
+
+func TestAuthenticate_logsInOnceForRejectedStoredSession(t *testing.T) {
+	var logins int32
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == accessTokenEndpoint {
+			atomic.AddInt32(&logins, 1)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}), WithTokenStore(NewMemoryTokenStore()))
+	c.tokens.Save(context.Background(), Session{Email: "user@example.com", Token: "expired"})
+
+	if err := c.Authenticate(context.Background(), "user@example.com", "wrong"); err != nil {
+		t.Fatalf("Authenticate returned error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Goals.ListAll(context.Background()); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Goals.ListAll returned error %v, want %v", err, ErrUnauthorized)
+		}
+	}
+
+	if n := atomic.LoadInt32(&logins); n != 1 {
+		t.Errorf("client logged in %d times, want 1", n)
+	}
+	if _, err := c.tokens.Load(context.Background()); !errors.Is(err, ErrNoSession) {
+		t.Errorf("TokenStore.Load returned error %v, want %v", err, ErrNoSession)
+	}
+}
+
+func TestSendWithAuth_withoutCredentialsProvider(t *testing.T) {
+	c := setup(t, &authHandler{token: "fresh"})
+
+	if _, err := c.Goals.ListAll(context.Background()); !errors.Is(err, ErrNotAuthenticated) {
+		t.Errorf("Goals.ListAll returned error %v, want %v", err, ErrNotAuthenticated)
+	}
+}
+
+func TestSendWithAuth_collapsesConcurrentRefreshes(t *testing.T) {
+	h := &authHandler{token: "fresh", delay: 20 * time.Millisecond}
+	c := setup(t, h, WithCredentialsProvider(testCredentials))
+	c.SetSession(Session{Email: "user@example.com", Token: "expired"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Goals.ListAll(context.Background()); err != nil {
+				t.Errorf("Goals.ListAll returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&h.logins); n != 1 {
+		t.Errorf("client logged in %d times, want 1", n)
+	}
+}
+
+func TestReauthenticate_outlivesFirstCaller(t *testing.T) {
+	h := &authHandler{token: "fresh", delay: 50 * time.Millisecond}
+	c := setup(t, h, WithCredentialsProvider(testCredentials))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.Goals.ListAll(ctx)
+		first <- err
+	}()
+
+	// Wait for the first caller to start the login.
+	for atomic.LoadInt32(&h.logins) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Errorf("second caller got error: %v", err)
+	}
+	if err := <-first; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("first caller got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := atomic.LoadInt32(&h.logins); n != 1 {
+		t.Errorf("client logged in %d times, want 1", n)
+	}
+}
+
+const (
+	testEmail = "user@example.com"
+	testToken = "s3cr3t-token"
+)
+
+var testSession = Session{Email: testEmail, Token: testToken}
+
+// assertRedacted fails t if s, described by what, contains
+// the credentials of testSession.
+func assertRedacted(t *testing.T, what, s string) {
+	t.Helper()
+
+	for _, secret := range []string{testEmail, url.QueryEscape(testEmail), testToken} {
+		if strings.Contains(s, secret) {
+			t.Errorf("%s %q contains %q", what, s, secret)
+		}
+	}
+}
+
+func TestAuthQuery_redactsAPIError(t *testing.T) {
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}), WithAuthMethod(AuthQuery))
+	c.SetSession(testSession)
+
+	_, err := c.Goals.ListAll(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Goals.ListAll returned error %v, want an *Error", err)
+	}
+	if !strings.Contains(apiErr.URL, redacted) {
+		t.Errorf("Error.URL = %q, want redacted credentials", apiErr.URL)
+	}
+	assertRedacted(t, "Error.URL", apiErr.URL)
+	assertRedacted(t, "Error()", apiErr.Error())
+}
+
+func TestAuthQuery_redactsTransportError(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"connection refused", []Option{WithBaseURL(closed.URL)}},
+		{"timeout", []Option{WithTimeout(10 * time.Millisecond)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Millisecond)
+			}), append([]Option{WithAuthMethod(AuthQuery)}, tt.opts...)...)
+			c.SetSession(testSession)
+
+			_, err := c.Goals.ListAll(context.Background())
+			var urlErr *url.Error
+			if !errors.As(err, &urlErr) {
+				t.Fatalf("Goals.ListAll returned error %v, want a *url.Error", err)
+			}
+			assertRedacted(t, "url.Error.URL", urlErr.URL)
+			assertRedacted(t, "Error()", err.Error())
+		})
+	}
+}
+
+func TestAuthHeader_isDefault(t *testing.T) {
+	var got *http.Request
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	c.SetSession(testSession)
+
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Fatalf("Goals.ListAll returned error: %v", err)
+	}
+	if v := got.Header.Get(userEmailHeader); v != testEmail {
+		t.Errorf("%s header = %q, want %q", userEmailHeader, v, testEmail)
+	}
+	if v := got.Header.Get(userTokenHeader); v != testToken {
+		t.Errorf("%s header = %q, want %q", userTokenHeader, v, testToken)
+	}
+	assertRedacted(t, "request URL", got.URL.String())
+}
+
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr error
+	}{
+		{http.StatusNoContent, nil},
+		{http.StatusUnauthorized, nil},
+		{http.StatusNotFound, nil},
+		{http.StatusInternalServerError, ErrServer},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var got *http.Request
+			store := NewMemoryTokenStore()
+			store.Save(context.Background(), testSession)
+			c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.WriteHeader(tt.status)
+			}), WithTokenStore(store))
+			c.SetSession(testSession)
+			if !c.IsAuthenticated() {
+				t.Fatal("IsAuthenticated before Logout = false, want true")
+			}
+
+			err := c.Logout(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Logout returned error %v, want %v", err, tt.wantErr)
+			}
+			if got == nil || got.Method != "DELETE" || got.URL.Path != accessTokenEndpoint {
+				t.Fatalf("Logout sent %v, want DELETE %s", got, accessTokenEndpoint)
+			}
+			if got.Header.Get(userEmailHeader) != testEmail || got.Header.Get(userTokenHeader) != testToken {
+				t.Errorf("Logout sent credentials %q, %q, want those of the session",
+					got.Header.Get(userEmailHeader), got.Header.Get(userTokenHeader))
+			}
+			if c.IsAuthenticated() {
+				t.Error("IsAuthenticated after Logout = true, want false")
+			}
+			if _, err := store.Load(context.Background()); !errors.Is(err, ErrNoSession) {
+				t.Errorf("TokenStore.Load after Logout returned error %v, want %v", err, ErrNoSession)
+			}
+		})
+	}
+}
+
+func TestLogout_withoutSession(t *testing.T) {
+	var requests int32
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+
+	if err := c.Logout(context.Background()); err != nil {
+		t.Errorf("Logout returned error: %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("Logout sent %d requests, want 0", n)
+	}
+	if c.IsAuthenticated() {
+		t.Error("IsAuthenticated = true, want false")
+	}
+}
+
+func TestAuthHeader_droppedOnRedirectToOtherHost(t *testing.T) {
+	var other http.Header
+	otherSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		other = r.Header.Clone()
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(otherSrv.Close)
+
+	var same http.Header
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case goalsEndpoint:
+			http.Redirect(w, r, "/moved", http.StatusFound)
+		case "/moved":
+			same = r.Header.Clone()
+			http.Redirect(w, r, otherSrv.URL+goalsEndpoint, http.StatusFound)
+		}
+	}))
+	c.SetSession(testSession)
+
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Fatalf("Goals.ListAll returned error: %v", err)
+	}
+	if same.Get(userEmailHeader) != testEmail || same.Get(userTokenHeader) != testToken {
+		t.Errorf("redirect to the same host dropped the credentials")
+	}
+	for _, k := range []string{userEmailHeader, userTokenHeader} {
+		if v := other.Get(k); v != "" {
+			t.Errorf("redirect to another host sent %s: %q", k, v)
+		}
+	}
+}
@@ -35,20 +35,30 @@ type BankListParams struct {
 //
 // Endpoint: GET /banks
 func (s *BanksService) ListAll(ctx context.Context, params *BankListParams) ([]*Bank, error) {
+	banks, _, err := s.ListAllWithResponse(ctx, params)
+	return banks, err
+}
+
+// ListAllWithResponse is like ListAll, but it also returns
+// the response of the API.
+func (s *BanksService) ListAllWithResponse(ctx context.Context, params *BankListParams) ([]*Bank, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "Banks", "ListAll", "/banks")
+	defer op.end()
+
 	url := s.client.baseURL.String() + banksEndpoint
 	url, err := addParams(url, params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var banks struct {
 		Data []*Bank `json:"data"`
 	}
 
-	err = s.client.get(ctx, url, &banks)
+	resp, err := s.client.get(ctx, url, &banks)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return banks.Data, nil
+	return banks.Data, resp, nil
 }
@@ -0,0 +1,176 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultBatchConcurrency = 4
+
+// BatchError reports the IDs of a batch call whose requests failed.
+// Results of the other IDs are still returned along with it.
+type BatchError struct {
+	Errors map[string]error // Errors by ID
+}
+
+func (e *BatchError) Error() string {
+	ids := e.ids()
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("%s: %v", id, e.Errors[id])
+	}
+	return fmt.Sprintf("fintual: %d batch requests failed: %s", len(ids), strings.Join(msgs, "; "))
+}
+
+// Is reports whether any error of the batch matches target.
+func (e *BatchError) Is(target error) bool {
+	for _, id := range e.ids() {
+		if errors.Is(e.Errors[id], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of the batch, by ID, which matches target,
+// and if one is found, sets target to it and returns true.
+func (e *BatchError) As(target interface{}) bool {
+	for _, id := range e.ids() {
+		if errors.As(e.Errors[id], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ids returns the sorted IDs of the failed requests.
+func (e *BatchError) ids() []string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// slotKey is the context key marking calls which hold a batch slot.
+type slotKey struct{}
+
+// acquireSlot waits for one of the client's batch slots, which bound the
+// concurrent requests of every batch call and split day range together.
+// The returned context marks the slot as held, so that nested calls make
+// their requests within it instead of waiting for another slot.
+func (c *Client) acquireSlot(ctx context.Context) (context.Context, error) {
+	select {
+	case c.batchSem <- struct{}{}:
+		return context.WithValue(ctx, slotKey{}, true), nil
+	case <-ctx.Done():
+		return ctx, ctx.Err()
+	}
+}
+
+// releaseSlot frees a slot taken by acquireSlot.
+func (c *Client) releaseSlot() {
+	<-c.batchSem
+}
+
+// holdsSlot reports whether ctx comes from acquireSlot.
+func holdsSlot(ctx context.Context) bool {
+	held, _ := ctx.Value(slotKey{}).(bool)
+	return held
+}
+
+// forEach calls fn for every distinct ID, each holding one of the client's
+// batch slots, so that up to the client's batch concurrency calls run at
+// a time across all batch methods. Every request still waits on the
+// client's rate limiter. It returns a *BatchError if any call fails.
+func (c *Client) forEach(ctx context.Context, ids []string, fn func(ctx context.Context, id string) error) error {
+	var (
+		mu   sync.Mutex
+		errs = make(map[string]error)
+		wg   sync.WaitGroup
+		seen = make(map[string]bool, len(ids))
+	)
+
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		slotCtx, err := c.acquireSlot(ctx)
+		if err != nil {
+			mu.Lock()
+			errs[id] = err
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(id string) {
+			defer func() {
+				c.releaseSlot()
+				wg.Done()
+			}()
+
+			if err := fn(slotCtx, id); err != nil {
+				mu.Lock()
+				errs[id] = err
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
+
+// GetMany retrieves the Real Assets with the given IDs concurrently.
+// Results are keyed by ID. If some requests fail, the results of the
+// others are returned along with a *BatchError.
+func (s *RealAssetsService) GetMany(ctx context.Context, ids []string) (map[string]*RealAsset, error) {
+	var mu sync.Mutex
+	assets := make(map[string]*RealAsset, len(ids))
+
+	err := s.client.forEach(ctx, ids, func(ctx context.Context, id string) error {
+		ra, err := s.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		assets[id] = ra
+		mu.Unlock()
+		return nil
+	})
+	return assets, err
+}
+
+// ListDaysByDatesMany lists the Real Asset Days of the Real Assets with
+// the given IDs concurrently, from and to the given dates. Results are
+// keyed by ID. If some requests fail, the results of the others are
+// returned along with a *BatchError.
+func (s *RealAssetsService) ListDaysByDatesMany(ctx context.Context, ids []string, from, to Date) (map[string][]*RealAssetDay, error) {
+	var mu sync.Mutex
+	days := make(map[string][]*RealAssetDay, len(ids))
+
+	err := s.client.forEach(ctx, ids, func(ctx context.Context, id string) error {
+		rad, err := s.ListDaysByDates(ctx, id, from, to)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		days[id] = rad
+		mu.Unlock()
+		return nil
+	})
+	return days, err
+}
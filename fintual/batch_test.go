@@ -0,0 +1,123 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// realAssetHandler serves the real asset whose ID is the last segment
+// of the path, or a 404 for the IDs in missing.
+func realAssetHandler(missing ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		for _, m := range missing {
+			if id == m {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message":"not found"}`))
+				return
+			}
+		}
+		fmt.Fprintf(w, `{"data":{"id":%q,"type":"real_asset"}}`, id)
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	c := setup(t, realAssetHandler())
+
+	assets, err := c.RealAssets.GetMany(context.Background(), []string{"1", "2", "2", "3"})
+	if err != nil {
+		t.Fatalf("RealAssets.GetMany returned error: %v", err)
+	}
+	if len(assets) != 3 {
+		t.Fatalf("got %d real assets, want 3", len(assets))
+	}
+	for id, ra := range assets {
+		if ra.ID != id {
+			t.Errorf("real asset keyed by %s has ID %s", id, ra.ID)
+		}
+	}
+}
+
+func TestGetMany_partialFailure(t *testing.T) {
+	c := setup(t, realAssetHandler("2"))
+
+	assets, err := c.RealAssets.GetMany(context.Background(), []string{"1", "2", "3"})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("RealAssets.GetMany returned error %v, want a *BatchError", err)
+	}
+	if len(batchErr.Errors) != 1 || batchErr.Errors["2"] == nil {
+		t.Errorf("BatchError.Errors = %v, want an error for ID 2 only", batchErr.Errors)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is(%v, ErrNotFound) = false, want true", err)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusNotFound {
+		t.Errorf("errors.As(%v, *Error) did not find the 404 error", err)
+	}
+	if assets["1"] == nil || assets["3"] == nil || assets["2"] != nil {
+		t.Errorf("got real assets %v, want IDs 1 and 3", assets)
+	}
+}
+
+func TestGetMany_boundsConcurrency(t *testing.T) {
+	h := &concurrencyHandler{h: realAssetHandler()}
+	c := setup(t, h, WithBatchConcurrency(2))
+
+	ids := []string{"1", "2", "3", "4", "5", "6"}
+	if _, err := c.RealAssets.GetMany(context.Background(), ids); err != nil {
+		t.Fatalf("RealAssets.GetMany returned error: %v", err)
+	}
+	if m := atomic.LoadInt32(&h.max); m > 2 {
+		t.Errorf("server handled %d concurrent requests, want at most 2", m)
+	}
+}
+
+func TestGetMany_canceledContext(t *testing.T) {
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		realAssetHandler()(w, r)
+	}), WithBatchConcurrency(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	ids := make([]string, 20)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assets, err := c.RealAssets.GetMany(ctx, ids)
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) {
+				t.Errorf("RealAssets.GetMany returned error %v, want a *BatchError", err)
+				return
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("errors.Is(%v, context.DeadlineExceeded) = false, want true", err)
+			}
+			if got := len(assets) + len(batchErr.Errors); got != len(ids) {
+				t.Errorf("got %d results and errors, want %d", got, len(ids))
+			}
+		}()
+	}
+	wg.Wait()
+}
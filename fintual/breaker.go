@@ -0,0 +1,186 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without making a request while
+// the client's circuit breaker is open.
+var ErrCircuitOpen = errors.New("fintual: circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every request with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through,
+	// which decide whether the circuit closes or opens again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerSettings configures a CircuitBreaker.
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failed attempts
+	// which opens the circuit. Transport errors and 5xx responses
+	// are failures. Defaults to 5.
+	FailureThreshold int
+
+	// OpenTimeout is how long the circuit stays open before
+	// letting probes through. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+
+	// HalfOpenProbes is the number of probes let through while the
+	// circuit is half-open. The circuit closes once all of them succeed,
+	// and opens again as soon as one fails. Defaults to 1.
+	HalfOpenProbes int
+
+	// OnStateChange, if set, is called every time the state
+	// of the circuit changes.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker fails requests fast while the API keeps failing,
+// instead of waiting for each of them to time out. It is safe for
+// concurrent use.
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int       // Consecutive failures while closed
+	openedAt  time.Time // When the circuit last opened
+	probes    int       // Probes let through while half-open
+	successes int       // Successful probes while half-open
+	gen       int       // Incremented on every state change
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker with the given settings.
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{settings: settings}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.settings.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may be made, returning ErrCircuitOpen
+// if it may not. Otherwise it returns the generation of the state which
+// admitted the request, to be passed to observe.
+func (b *CircuitBreaker) allow() (int, error) {
+	b.mu.Lock()
+	from := b.state
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.settings.OpenTimeout {
+		b.setState(CircuitHalfOpen)
+	}
+
+	var err error
+	switch {
+	case b.state == CircuitOpen:
+		err = ErrCircuitOpen
+	case b.state == CircuitHalfOpen && b.probes >= b.settings.HalfOpenProbes:
+		err = ErrCircuitOpen
+	case b.state == CircuitHalfOpen:
+		b.probes++
+	}
+
+	to, gen := b.state, b.gen
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return gen, err
+}
+
+// observe records the outcome of a request let through by allow with the
+// given generation. Requests admitted before the last state change are not
+// counted, so that a request let through while closed is never taken for
+// a probe. Neither are requests whose context is done, since they did not
+// fail because of the API.
+func (b *CircuitBreaker) observe(ctx context.Context, gen int, resp *http.Response, err error) {
+	failed := err != nil || resp.StatusCode >= 500
+
+	b.mu.Lock()
+	from := b.state
+
+	switch {
+	case gen != b.gen:
+	case ctx.Err() != nil:
+		if b.state == CircuitHalfOpen && b.probes > 0 {
+			b.probes--
+		}
+	case b.state == CircuitClosed && !failed:
+		b.failures = 0
+	case b.state == CircuitClosed:
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.setState(CircuitOpen)
+		}
+	case b.state == CircuitHalfOpen && failed:
+		b.setState(CircuitOpen)
+	case b.state == CircuitHalfOpen:
+		b.successes++
+		if b.successes >= b.settings.HalfOpenProbes {
+			b.setState(CircuitClosed)
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// setState moves the circuit to the given state. b.mu must be held.
+func (b *CircuitBreaker) setState(to CircuitState) {
+	b.state = to
+	b.gen++
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+	if to == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+}
+
+// notify calls the OnStateChange callback if the state changed.
+// b.mu must not be held, so that the callback may use b.
+func (b *CircuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,175 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	okResponse     = &http.Response{StatusCode: http.StatusOK}
+	failedResponse = &http.Response{StatusCode: http.StatusInternalServerError}
+)
+
+// recordTransitions returns settings recording every state change in transitions.
+func recordTransitions(settings CircuitBreakerSettings, transitions *[]string) CircuitBreakerSettings {
+	settings.OnStateChange = func(from, to CircuitState) {
+		*transitions = append(*transitions, from.String()+"->"+to.String())
+	}
+	return settings
+}
+
+// mustAllow calls b.allow, failing the test if the request is not let through.
+func mustAllow(t *testing.T, b *CircuitBreaker) int {
+	t.Helper()
+
+	gen, err := b.allow()
+	if err != nil {
+		t.Fatalf("allow returned error: %v", err)
+	}
+	return gen
+}
+
+func TestCircuitBreaker_opensAtThreshold(t *testing.T) {
+	var transitions []string
+	b := NewCircuitBreaker(recordTransitions(CircuitBreakerSettings{FailureThreshold: 3}, &transitions))
+	ctx := context.Background()
+
+	// A success resets the count of consecutive failures.
+	for _, resp := range []*http.Response{failedResponse, failedResponse, okResponse, failedResponse, failedResponse} {
+		b.observe(ctx, mustAllow(t, b), resp, nil)
+	}
+	if s := b.State(); s != CircuitClosed {
+		t.Fatalf("state is %s after 2 consecutive failures, want closed", s)
+	}
+
+	b.observe(ctx, mustAllow(t, b), nil, errors.New("connection reset"))
+	if s := b.State(); s != CircuitOpen {
+		t.Fatalf("state is %s after 3 consecutive failures, want open", s)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow returned error %v, want %v", err, ErrCircuitOpen)
+	}
+	if want := []string{"closed->open"}; !reflect.DeepEqual(transitions, want) {
+		t.Errorf("got transitions %v, want %v", transitions, want)
+	}
+}
+
+func TestCircuitBreaker_halfOpenProbes(t *testing.T) {
+	var transitions []string
+	b := NewCircuitBreaker(recordTransitions(CircuitBreakerSettings{
+		FailureThreshold: 1,
+		OpenTimeout:      10 * time.Millisecond,
+		HalfOpenProbes:   2,
+	}, &transitions))
+	ctx := context.Background()
+
+	b.observe(ctx, mustAllow(t, b), failedResponse, nil)
+	time.Sleep(10 * time.Millisecond)
+	if s := b.State(); s != CircuitHalfOpen {
+		t.Fatalf("state is %s after OpenTimeout, want half-open", s)
+	}
+
+	first, second := mustAllow(t, b), mustAllow(t, b)
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("third probe: allow returned error %v, want %v", err, ErrCircuitOpen)
+	}
+
+	b.observe(ctx, first, okResponse, nil)
+	if s := b.State(); s != CircuitHalfOpen {
+		t.Fatalf("state is %s after 1 of 2 successful probes, want half-open", s)
+	}
+	b.observe(ctx, second, okResponse, nil)
+	if s := b.State(); s != CircuitClosed {
+		t.Fatalf("state is %s after 2 successful probes, want closed", s)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("got transitions %v, want %v", transitions, want)
+	}
+}
+
+func TestCircuitBreaker_reopensOnFailedProbe(t *testing.T) {
+	var transitions []string
+	b := NewCircuitBreaker(recordTransitions(CircuitBreakerSettings{
+		FailureThreshold: 1,
+		OpenTimeout:      10 * time.Millisecond,
+	}, &transitions))
+	ctx := context.Background()
+
+	b.observe(ctx, mustAllow(t, b), failedResponse, nil)
+	time.Sleep(10 * time.Millisecond)
+	b.observe(ctx, mustAllow(t, b), failedResponse, nil)
+
+	if s := b.State(); s != CircuitOpen {
+		t.Fatalf("state is %s after a failed probe, want open", s)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow returned error %v, want %v", err, ErrCircuitOpen)
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->open"}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("got transitions %v, want %v", transitions, want)
+	}
+}
+
+func TestCircuitBreaker_ignoresRequestsAdmittedBeforeHalfOpen(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	slow := mustAllow(t, b)
+	b.observe(ctx, mustAllow(t, b), failedResponse, nil)
+	time.Sleep(10 * time.Millisecond)
+	probe := mustAllow(t, b)
+
+	// The slow request was let through while closed, so its success
+	// neither closes the circuit nor frees the probe's slot.
+	b.observe(ctx, slow, okResponse, nil)
+	if s := b.State(); s != CircuitHalfOpen {
+		t.Fatalf("state is %s after a non-probe success, want half-open", s)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow returned error %v, want %v", err, ErrCircuitOpen)
+	}
+
+	b.observe(ctx, probe, okResponse, nil)
+	if s := b.State(); s != CircuitClosed {
+		t.Errorf("state is %s after a successful probe, want closed", s)
+	}
+}
+
+func TestCircuitBreaker_ignoresCanceledRequests(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b.observe(ctx, mustAllow(t, b), nil, context.Canceled)
+	if s := b.State(); s != CircuitClosed {
+		t.Errorf("state is %s after a canceled request, want closed", s)
+	}
+}
+
+func TestSend_failsFastWhileCircuitOpen(t *testing.T) {
+	var requests int32
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}), WithCircuitBreaker(NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 2})))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Banks.ListAll(context.Background(), nil); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: Banks.ListAll returned %v before the threshold", i, err)
+		}
+	}
+	if _, err := c.Banks.ListAll(context.Background(), nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Banks.ListAll returned error %v, want %v", err, ErrCircuitOpen)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("server received %d requests, want 2", n)
+	}
+}
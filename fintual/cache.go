@@ -0,0 +1,256 @@
+package fintual
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses of the API for a response cache.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key, if any.
+	Get(key string) ([]byte, bool)
+	// Set stores value for key.
+	Set(key string, value []byte)
+	// Delete removes the value stored for key.
+	Delete(key string)
+}
+
+// catalogEndpoints are the endpoints whose responses are cached with the
+// default time to live, since they rarely change. Other endpoints are only
+// cached if given a time to live with WithCacheTTL.
+var catalogEndpoints = []string{"/banks", "/asset_providers", "/conceptual_assets"}
+
+// responseCache caches the responses of unauthenticated GET requests.
+type responseCache struct {
+	store Cache
+	ttl   time.Duration            // Time to live of the entries of catalog endpoints
+	ttls  map[string]time.Duration // Time to live of entries by endpoint
+}
+
+// cacheEntry is a response stored in a Cache.
+type cacheEntry struct {
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// ttlOf returns the time to live of the responses of the given endpoint.
+// It reports false if they are not cached.
+func (rc *responseCache) ttlOf(endpoint string) (time.Duration, bool) {
+	if ttl, ok := rc.ttls[endpoint]; ok {
+		return ttl, ttl >= 0
+	}
+	for _, e := range catalogEndpoints {
+		if e == endpoint {
+			return rc.ttl, rc.ttl >= 0
+		}
+	}
+	return 0, false
+}
+
+func (rc *responseCache) load(key string) (*cacheEntry, bool) {
+	b, ok := rc.store.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		rc.store.Delete(key)
+		return nil, false
+	}
+	return &e, true
+}
+
+func (rc *responseCache) save(key string, e *cacheEntry) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	rc.store.Set(key, b)
+}
+
+// getCached makes a GET request through the client's response cache.
+// Fresh responses are served from the cache, while stale ones are
+// revalidated with a conditional request.
+func (c *Client) getCached(req *http.Request, ttl time.Duration, v interface{}) (*Response, error) {
+	key := req.Method + " " + req.URL.String()
+	endpoint := endpointOf(req)
+
+	e, ok := c.cache.load(key)
+	if ok && time.Since(e.StoredAt) < ttl {
+		return c.serveCached(req, e, v)
+	}
+	if ok {
+		if etag := e.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lm := e.Header.Get("Last-Modified"); lm != "" {
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	var raw json.RawMessage
+	resp, err := c.send(req, &raw)
+	if err != nil {
+		return resp, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		e.StoredAt = time.Now()
+		c.cache.save(key, e)
+		if c.metrics != nil {
+			c.metrics.IncCacheHits(endpoint)
+		}
+		if err := json.Unmarshal(e.Body, v); err != nil {
+			return nil, err
+		}
+		return &Response{
+			Response: cachedHTTPResponse(req, e.Header),
+			Attempts: resp.Attempts,
+			Duration: resp.Duration,
+			Cached:   true,
+		}, nil
+	}
+
+	c.cache.save(key, &cacheEntry{Header: resp.Header, Body: raw, StoredAt: time.Now()})
+	return resp, json.Unmarshal(raw, v)
+}
+
+// serveCached unmarshals the body of e into v, without making a request.
+func (c *Client) serveCached(req *http.Request, e *cacheEntry, v interface{}) (*Response, error) {
+	if c.metrics != nil {
+		c.metrics.IncCacheHits(endpointOf(req))
+	}
+	if err := json.Unmarshal(e.Body, v); err != nil {
+		return nil, err
+	}
+
+	return &Response{Response: cachedHTTPResponse(req, e.Header), Cached: true}, nil
+}
+
+// LRUCache is an in-memory Cache which evicts the least
+// recently used entries once it is full.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	value []byte
+}
+
+// NewLRUCache returns an LRUCache holding up to maxEntries entries.
+// If maxEntries is zero, the cache has no limit.
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (l *LRUCache) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	l.ll.MoveToFront(el)
+	return el.Value.(*lruItem).value, true
+}
+
+func (l *LRUCache) Set(key string, value []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		el.Value.(*lruItem).value = value
+		l.ll.MoveToFront(el)
+		return
+	}
+
+	l.items[key] = l.ll.PushFront(&lruItem{key: key, value: value})
+	if l.maxEntries > 0 && l.ll.Len() > l.maxEntries {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+func (l *LRUCache) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		l.ll.Remove(el)
+		delete(l.items, key)
+	}
+}
+
+// DiskCache is a Cache which stores every entry in a file
+// of a directory. Errors reading or writing files are ignored,
+// and treated as cache misses.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing entries in dir,
+// which is created if it does not exist.
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+// path returns the path of the file storing key.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	b, err := ioutil.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// Set writes value to a temporary file which then replaces the
+// entry's file, so that readers never see a partial entry.
+func (d *DiskCache) Set(key string, value []byte) {
+	if err := os.MkdirAll(d.dir, 0700); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(d.dir, "tmp*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(value)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), d.path(key))
+}
+
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
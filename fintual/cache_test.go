@@ -0,0 +1,168 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// etagHandler serves the same body with an ETag, answering
+// requests which carry it with a 304.
+type etagHandler struct {
+	requests    int32
+	revalidated int32
+}
+
+func (h *etagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&h.requests, 1)
+	if r.Header.Get("If-None-Match") == `"v1"` {
+		atomic.AddInt32(&h.revalidated, 1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", `"v1"`)
+	switch r.URL.Path {
+	case banksEndpoint:
+		w.Write([]byte(`{"data":[{"id":"1","type":"bank","attributes":{"name":"Banco Nova"}}]}`))
+	default:
+		w.Write([]byte(`{"data":{"id":"186","type":"real_asset"}}`))
+	}
+}
+
+func TestCache_servesFreshResponses(t *testing.T) {
+	h := &etagHandler{}
+	c := setup(t, h, WithCache(NewLRUCache(10), time.Hour))
+
+	for i := 0; i < 3; i++ {
+		banks, resp, err := c.Banks.ListAllWithResponse(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("Banks.ListAll returned error: %v", err)
+		}
+		if len(banks) != 1 || banks[0].Attributes.Name != "Banco Nova" {
+			t.Errorf("Banks.ListAll returned %+v", banks)
+		}
+		if resp.Cached != (i > 0) {
+			t.Errorf("call %d: Response.Cached = %v", i, resp.Cached)
+		}
+	}
+	if n := atomic.LoadInt32(&h.requests); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
+
+func TestCache_revalidatesStaleResponses(t *testing.T) {
+	h := &etagHandler{}
+	tracer := &testTracer{}
+	c := setup(t, h, WithCache(NewLRUCache(10), 0), WithTracer(tracer))
+
+	for i := 0; i < 2; i++ {
+		banks, resp, err := c.Banks.ListAllWithResponse(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("Banks.ListAll returned error: %v", err)
+		}
+		if len(banks) != 1 || banks[0].Attributes.Name != "Banco Nova" {
+			t.Errorf("Banks.ListAll returned %+v", banks)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Cached != (i > 0) {
+			t.Errorf("call %d: got status %d, cached %v", i, resp.StatusCode, resp.Cached)
+		}
+	}
+
+	if n := atomic.LoadInt32(&h.revalidated); n != 1 {
+		t.Errorf("server revalidated %d requests, want 1", n)
+	}
+	for _, s := range tracer.named("Banks.ListAll") {
+		if s.err != nil {
+			t.Errorf("Banks.ListAll span ended with error: %v", s.err)
+		}
+	}
+}
+
+func TestCache_skipsOtherEndpoints(t *testing.T) {
+	h := &etagHandler{}
+	c := setup(t, h, WithCache(NewLRUCache(10), time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.RealAssets.Get(context.Background(), "186"); err != nil {
+			t.Fatalf("RealAssets.Get returned error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&h.requests); n != 2 {
+		t.Errorf("server received %d requests, want 2", n)
+	}
+}
+
+func TestCache_optInEndpoint(t *testing.T) {
+	h := &etagHandler{}
+	c := setup(t, h,
+		WithCache(NewLRUCache(10), time.Hour),
+		WithCacheTTL("/real_assets/:id", time.Hour),
+		WithCacheTTL("/banks", -1),
+	)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.RealAssets.Get(context.Background(), "186"); err != nil {
+			t.Fatalf("RealAssets.Get returned error: %v", err)
+		}
+		if _, err := c.Banks.ListAll(context.Background(), nil); err != nil {
+			t.Fatalf("Banks.ListAll returned error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&h.requests); n != 3 {
+		t.Errorf("server received %d requests, want 3", n)
+	}
+}
+
+func TestCache_skipsAuthenticatedEndpoints(t *testing.T) {
+	h := &authHandler{token: "token"}
+	c := setup(t, h, WithCache(NewLRUCache(10), time.Hour), WithCacheTTL("/goals", time.Hour))
+	c.SetSession(Session{Email: "user@example.com", Token: "token"})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Goals.ListAll(context.Background()); err != nil {
+			t.Fatalf("Goals.ListAll returned error: %v", err)
+		}
+	}
+
+	// A new session must not be served the previous one's goals.
+	c.SetSession(Session{Email: "other@example.com", Token: "other"})
+	if _, err := c.Goals.ListAll(context.Background()); err == nil {
+		t.Error("Goals.ListAll with an invalid token returned no error")
+	}
+}
+
+func TestLRUCache_evictsLeastRecentlyUsed(t *testing.T) {
+	l := NewLRUCache(2)
+	l.Set("a", []byte("1"))
+	l.Set("b", []byte("2"))
+	l.Get("a")
+	l.Set("c", []byte("3"))
+
+	if _, ok := l.Get("b"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := l.Get(k); !ok {
+			t.Errorf("entry %s was evicted", k)
+		}
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	d := NewDiskCache(t.TempDir())
+
+	if _, ok := d.Get("k"); ok {
+		t.Fatal("empty DiskCache returned an entry")
+	}
+	d.Set("k", []byte("v"))
+	if v, ok := d.Get("k"); !ok || string(v) != "v" {
+		t.Errorf("Get returned %q, %v, want %q, true", v, ok, "v")
+	}
+	d.Delete("k")
+	if _, ok := d.Get("k"); ok {
+		t.Error("deleted entry is still returned")
+	}
+}
@@ -44,40 +44,60 @@ type ConceptualAssetListParams struct {
 //
 // Endpoint: GET /conceptual_assets
 func (s *ConceptualAssetsService) ListAll(ctx context.Context, params *ConceptualAssetListParams) ([]*ConceptualAsset, error) {
+	ca, _, err := s.ListAllWithResponse(ctx, params)
+	return ca, err
+}
+
+// ListAllWithResponse is like ListAll, but it also returns
+// the response of the API.
+func (s *ConceptualAssetsService) ListAllWithResponse(ctx context.Context, params *ConceptualAssetListParams) ([]*ConceptualAsset, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "ConceptualAssets", "ListAll", "/conceptual_assets")
+	defer op.end()
+
 	url := s.client.baseURL.String() + conceptualAssetsEndpoint
 	url, err := addParams(url, params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var ca struct {
 		Data []*ConceptualAsset `json:"data"`
 	}
 
-	err = s.client.get(ctx, url, &ca)
+	resp, err := s.client.get(ctx, url, &ca)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return ca.Data, nil
+	return ca.Data, resp, nil
 }
 
 // Get retrieves a single Conceptual Asset.
 //
 // Endpoint: GET /conceptual_assets/:id
 func (s *ConceptualAssetsService) Get(ctx context.Context, id string) (*ConceptualAsset, error) {
+	ca, _, err := s.GetWithResponse(ctx, id)
+	return ca, err
+}
+
+// GetWithResponse is like Get, but it also returns
+// the response of the API.
+func (s *ConceptualAssetsService) GetWithResponse(ctx context.Context, id string) (*ConceptualAsset, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "ConceptualAssets", "Get", "/conceptual_assets/:id", attr(attrResourceID, id))
+	defer op.end()
+
 	url := fmt.Sprintf("%s/%s", s.client.baseURL.String()+conceptualAssetsEndpoint, id)
 
 	var ca struct {
 		Data *ConceptualAsset `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &ca)
+	resp, err := s.client.get(ctx, url, &ca)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return ca.Data, nil
+	return ca.Data, resp, nil
 }
 
 // ListByAssetProvider lists all Conceptual Assets
@@ -87,20 +107,30 @@ func (s *ConceptualAssetsService) Get(ctx context.Context, id string) (*Conceptu
 //
 // Endpoint: GET /asset_providers/:id/conceptual_assets
 func (s *ConceptualAssetsService) ListByAssetProvider(ctx context.Context, id string, params *ConceptualAssetListParams) ([]*ConceptualAsset, error) {
+	ca, _, err := s.ListByAssetProviderWithResponse(ctx, id, params)
+	return ca, err
+}
+
+// ListByAssetProviderWithResponse is like ListByAssetProvider, but it also returns
+// the response of the API.
+func (s *ConceptualAssetsService) ListByAssetProviderWithResponse(ctx context.Context, id string, params *ConceptualAssetListParams) ([]*ConceptualAsset, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "ConceptualAssets", "ListByAssetProvider", "/asset_providers/:id/conceptual_assets", attr(attrResourceID, id))
+	defer op.end()
+
 	url := fmt.Sprintf("%s/%s/%s", s.client.baseURL.String()+assetProvidersEndpoint, id, conceptualAssetsEndpoint)
 	url, err := addParams(url, params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var ca struct {
 		Data []*ConceptualAsset `json:"data"`
 	}
 
-	err = s.client.get(ctx, url, &ca)
+	resp, err := s.client.get(ctx, url, &ca)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return ca.Data, nil
+	return ca.Data, resp, nil
 }
@@ -0,0 +1,71 @@
+package fintual
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	emailEnv    = "FINTUAL_EMAIL"
+	passwordEnv = "FINTUAL_PASSWORD"
+)
+
+// CredentialsProvider supplies the credentials used to authenticate a Client.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsFunc is an adapter to allow the use of ordinary
+// functions as a CredentialsProvider.
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials calls f(ctx).
+func (f CredentialsFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// EnvCredentials returns a CredentialsProvider which reads the credentials
+// from the FINTUAL_EMAIL and FINTUAL_PASSWORD environment variables.
+func EnvCredentials() CredentialsProvider {
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		creds := Credentials{Email: os.Getenv(emailEnv), Password: os.Getenv(passwordEnv)}
+		if creds.Email == "" || creds.Password == "" {
+			return Credentials{}, errors.New("fintual: " + emailEnv + " and " + passwordEnv + " must be set")
+		}
+		return creds, nil
+	})
+}
+
+// FileCredentials returns a CredentialsProvider which reads the credentials
+// from a JSON file at path, with the form {"email": "...", "password": "..."}.
+// The file is read every time credentials are needed.
+func FileCredentials(path string) CredentialsProvider {
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		var creds Credentials
+		if err := json.Unmarshal(b, &creds); err != nil {
+			return Credentials{}, err
+		}
+		if creds.Email == "" || creds.Password == "" {
+			return Credentials{}, errors.New("fintual: credentials file " + path + " is missing email or password")
+		}
+		return creds, nil
+	})
+}
+
+// AuthenticateWith authenticates the client with the
+// credentials supplied by p. See Client.Authenticate.
+func (c *Client) AuthenticateWith(ctx context.Context, p CredentialsProvider) error {
+	creds, err := p.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Authenticate(ctx, creds.Email, creds.Password)
+}
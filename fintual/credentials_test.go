@@ -0,0 +1,106 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  bool
+	}{
+		{"set", "user@example.com", "secret", false},
+		{"missing email", "", "secret", true},
+		{"missing password", "user@example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(emailEnv, tt.email)
+			t.Setenv(passwordEnv, tt.password)
+
+			creds, err := EnvCredentials().Credentials(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Credentials returned error %v, want error %v", err, tt.wantErr)
+			}
+			if want := (Credentials{Email: tt.email, Password: tt.password}); err == nil && creds != want {
+				t.Errorf("Credentials returned %+v, want %+v", creds, want)
+			}
+		})
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // File is not created if empty
+		want    Credentials
+		wantErr bool
+	}{
+		{"valid", `{"email":"user@example.com","password":"secret"}`, Credentials{Email: "user@example.com", Password: "secret"}, false},
+		{"missing email", `{"password":"secret"}`, Credentials{}, true},
+		{"missing password", `{"email":"user@example.com"}`, Credentials{}, true},
+		{"malformed", `{"email":`, Credentials{}, true},
+		{"not found", ``, Credentials{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "credentials.json")
+			if tt.content != "" {
+				if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			creds, err := FileCredentials(path).Credentials(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Credentials returned error %v, want error %v", err, tt.wantErr)
+			}
+			if creds != tt.want {
+				t.Errorf("Credentials returned %+v, want %+v", creds, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWith(t *testing.T) {
+	h := &authHandler{token: "fresh"}
+	c := setup(t, h)
+
+	if err := c.AuthenticateWith(context.Background(), testCredentials); err != nil {
+		t.Fatalf("AuthenticateWith returned error: %v", err)
+	}
+	if s := c.Session(); s.Email != "user@example.com" || s.Token != "fresh" {
+		t.Errorf("session is %+v, want the fresh token of user@example.com", s)
+	}
+	if n := atomic.LoadInt32(&h.logins); n != 1 {
+		t.Errorf("client logged in %d times, want 1", n)
+	}
+}
+
+func TestAuthenticateWith_providerError(t *testing.T) {
+	h := &authHandler{token: "fresh"}
+	c := setup(t, h)
+	errProvider := errors.New("vault unavailable")
+
+	err := c.AuthenticateWith(context.Background(), CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		return Credentials{}, errProvider
+	}))
+	if !errors.Is(err, errProvider) {
+		t.Errorf("AuthenticateWith returned error %v, want %v", err, errProvider)
+	}
+	if n := atomic.LoadInt32(&h.logins); n != 0 {
+		t.Errorf("client logged in %d times, want 0", n)
+	}
+	if c.IsAuthenticated() {
+		t.Error("IsAuthenticated = true, want false")
+	}
+}
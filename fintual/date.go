@@ -0,0 +1,126 @@
+package fintual
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayout is the format of dates in the API, YYYY-MM-DD.
+const dateLayout = "2006-01-02"
+
+// Date is a calendar date, without a time of day nor a time zone,
+// such as the date of a Real Asset Day. It is marshalled to and from
+// JSON as a YYYY-MM-DD string. The zero date is marshalled as an empty
+// string, and a null date leaves it unchanged.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date on which t falls, in the location of t.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate parses a date with format YYYY-MM-DD.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("fintual: invalid date %q", s)
+	}
+	return DateOf(t), nil
+}
+
+// String returns the date with format YYYY-MM-DD.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// IsZero reports whether d is the zero date.
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// IsValid reports whether d is an existing date, e.g. not February 30th.
+func (d Date) IsValid() bool {
+	return DateOf(d.In(time.UTC)) == d
+}
+
+// In returns the time at which d starts in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns the date n days after d, or before it if n is negative.
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.In(time.UTC).AddDate(0, 0, n))
+}
+
+// DaysSince returns the number of days from s to d,
+// which is negative if d is before s.
+func (d Date) DaysSince(s Date) int {
+	return int(d.In(time.UTC).Sub(s.In(time.UTC)) / (24 * time.Hour))
+}
+
+// Before reports whether d is before d2.
+func (d Date) Before(d2 Date) bool {
+	return d.Compare(d2) < 0
+}
+
+// After reports whether d is after d2.
+func (d Date) After(d2 Date) bool {
+	return d.Compare(d2) > 0
+}
+
+// Compare returns -1 if d is before d2, +1 if it is after it, and 0 otherwise.
+func (d Date) Compare(d2 Date) int {
+	switch {
+	case d.Year != d2.Year:
+		return cmp(d.Year, d2.Year)
+	case d.Month != d2.Month:
+		return cmp(int(d.Month), int(d2.Month))
+	}
+	return cmp(d.Day, d2.Day)
+}
+
+// MarshalText implements encoding.TextMarshaler, formatting d
+// as YYYY-MM-DD, or as an empty text if d is the zero date.
+func (d Date) MarshalText() ([]byte, error) {
+	if d.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing
+// a YYYY-MM-DD date. An empty text is parsed as the zero date.
+func (d *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Date{}
+		return nil
+	}
+
+	date, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
+}
+
+// today returns the current date in UTC.
+func today() Date {
+	return DateOf(time.Now().UTC())
+}
+
+func cmp(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
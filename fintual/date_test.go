@@ -0,0 +1,122 @@
+package fintual
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Date
+		wantErr bool
+	}{
+		{s: "2020-02-29", want: Date{2020, time.February, 29}},
+		{s: "1999-12-31", want: Date{1999, time.December, 31}},
+		{s: "a-b-c", wantErr: true},
+		{s: "2021-02-29", wantErr: true},
+		{s: "2020-1-1", wantErr: true},
+		{s: "2020-01-01T00:00:00Z", wantErr: true},
+		{s: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDate(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDate(%q) returned error %v, want error: %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDate(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDate_arithmetic(t *testing.T) {
+	d := Date{2020, time.December, 30}
+
+	if got, want := d.AddDays(3), (Date{2021, time.January, 2}); got != want {
+		t.Errorf("AddDays(3) = %v, want %v", got, want)
+	}
+	if got, want := d.AddDays(-366), (Date{2019, time.December, 30}); got != want {
+		t.Errorf("AddDays(-366) = %v, want %v", got, want)
+	}
+	if got := d.AddDays(40).DaysSince(d); got != 40 {
+		t.Errorf("DaysSince = %d, want 40", got)
+	}
+	if got := d.DaysSince(d.AddDays(5)); got != -5 {
+		t.Errorf("DaysSince = %d, want -5", got)
+	}
+	if !d.Before(d.AddDays(1)) || d.After(d.AddDays(1)) || d.Compare(d) != 0 {
+		t.Errorf("comparisons of %v with the next day are wrong", d)
+	}
+	if (Date{}).IsValid() || (Date{2021, time.February, 29}).IsValid() || !d.IsValid() {
+		t.Error("IsValid is wrong")
+	}
+}
+
+func TestDate_marshalJSON(t *testing.T) {
+	tests := []struct {
+		date Date
+		want string
+	}{
+		{Date{2020, time.March, 1}, `"2020-03-01"`},
+		{Date{}, `""`},
+	}
+
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.date)
+		if err != nil {
+			t.Fatalf("json.Marshal(%v) returned error: %v", tt.date, err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("json.Marshal(%v) = %s, want %s", tt.date, b, tt.want)
+		}
+	}
+}
+
+func TestDate_unmarshalNull(t *testing.T) {
+	v := struct {
+		D Date  `json:"d"`
+		P *Date `json:"p"`
+	}{D: Date{2020, time.March, 1}}
+
+	if err := json.Unmarshal([]byte(`{"d":null,"p":null}`), &v); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	if v.D != (Date{2020, time.March, 1}) || v.P != nil {
+		t.Errorf("null dates changed the values to %v and %v", v.D, v.P)
+	}
+}
+
+func TestDate_roundTrip(t *testing.T) {
+	end := Date{2021, time.March, 4}
+	previous := 12
+	tests := []interface{}{
+		&RealAsset{},
+		&RealAsset{ID: "186", Attributes: RealAssetAttributes{
+			StartDate:       Date{2018, time.June, 1},
+			EndDate:         &end,
+			PreviousAssetID: &previous,
+			LastDay:         LastDay{Date: Date{2021, time.March, 3}},
+		}},
+		&RealAssetDay{Attributes: RealAssetDayAttributes{Date: Date{2020, time.February, 29}, Price: 1234.5}},
+		&ConceptualAssetRealAsset{},
+	}
+
+	for _, want := range tests {
+		b, err := json.Marshal(want)
+		if err != nil {
+			t.Fatalf("json.Marshal(%+v) returned error: %v", want, err)
+		}
+		got := reflect.New(reflect.TypeOf(want).Elem()).Interface()
+		if err := json.Unmarshal(b, got); err != nil {
+			t.Fatalf("json.Unmarshal(%s) returned error: %v", b, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip of %+v returned %+v", want, got)
+		}
+	}
+}
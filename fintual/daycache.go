@@ -0,0 +1,230 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// unsettledDays is the number of most recent days whose
+// coverage is never cached, since they may still be published.
+const unsettledDays = 3
+
+// DayCache caches the days of real assets fetched by
+// RealAssetsService.ListDaysByDates. Past days do not change once
+// published, so only the parts of a requested range which have
+// never been fetched are requested to the API.
+//
+// The most recent days of a range are always requested again, since
+// they may not be published yet. Use InvalidateLastDays to drop more
+// days, e.g. when the API revises them. A DayCache is safe for
+// concurrent use, and may be shared by several clients.
+type DayCache struct {
+	mu     sync.Mutex
+	assets map[string]*assetDays // Cached days by real asset ID
+}
+
+// assetDays are the cached days of a real asset.
+type assetDays struct {
+	days    map[Date]*RealAssetDay // Days by date
+	covered []dateRange            // Sorted, non-overlapping ranges which were fetched
+}
+
+// dateRange is an inclusive range of dates.
+type dateRange struct {
+	from, to Date
+}
+
+// NewDayCache returns an empty DayCache.
+func NewDayCache() *DayCache {
+	return &DayCache{assets: make(map[string]*assetDays)}
+}
+
+// Invalidate drops every cached day of the real asset with the given ID.
+func (dc *DayCache) Invalidate(id string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	delete(dc.assets, id)
+}
+
+// InvalidateLastDays drops the cached days of every real asset
+// within the last n days, so that they are requested again.
+func (dc *DayCache) InvalidateLastDays(n int) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	since := today().AddDays(-n + 1)
+	for _, a := range dc.assets {
+		a.drop(since)
+	}
+}
+
+// missing returns the parts of [from, to] which are not cached
+// for the real asset with the given ID.
+func (dc *DayCache) missing(id string, from, to Date) []dateRange {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	a, ok := dc.assets[id]
+	if !ok {
+		return []dateRange{{from, to}}
+	}
+
+	var gaps []dateRange
+	next := from
+	for _, r := range a.covered {
+		if r.to.Before(next) {
+			continue
+		}
+		if r.from.After(to) {
+			break
+		}
+		if r.from.After(next) {
+			gaps = append(gaps, dateRange{next, r.from.AddDays(-1)})
+		}
+		next = r.to.AddDays(1)
+		if next.After(to) {
+			return gaps
+		}
+	}
+	return append(gaps, dateRange{next, to})
+}
+
+// add caches the days fetched for [from, to].
+func (dc *DayCache) add(id string, from, to Date, days []*RealAssetDay) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	a, ok := dc.assets[id]
+	if !ok {
+		a = &assetDays{days: make(map[Date]*RealAssetDay)}
+		dc.assets[id] = a
+	}
+
+	for _, d := range days {
+		cp := *d
+		a.days[d.Attributes.Date] = &cp
+	}
+
+	if settled := today().AddDays(-unsettledDays); to.After(settled) {
+		to = settled
+	}
+	if !to.Before(from) {
+		a.cover(dateRange{from, to})
+	}
+}
+
+// get returns copies of the cached days of the real asset
+// with the given ID within [from, to], sorted by date.
+func (dc *DayCache) get(id string, from, to Date) []*RealAssetDay {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	a, ok := dc.assets[id]
+	if !ok {
+		return nil
+	}
+
+	var days []*RealAssetDay
+	for date, d := range a.days {
+		if date.Before(from) || date.After(to) {
+			continue
+		}
+		cp := *d
+		days = append(days, &cp)
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Attributes.Date.Before(days[j].Attributes.Date)
+	})
+	return days
+}
+
+// cover adds r to the covered ranges, merging adjacent ones.
+func (a *assetDays) cover(r dateRange) {
+	ranges := append(a.covered, r)
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].from.Before(ranges[j].from)
+	})
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.from.After(last.to.AddDays(1)) {
+			merged = append(merged, r)
+			continue
+		}
+		if r.to.After(last.to) {
+			last.to = r.to
+		}
+	}
+	a.covered = merged
+}
+
+// drop removes the days and coverage from since onwards.
+func (a *assetDays) drop(since Date) {
+	for date := range a.days {
+		if !date.Before(since) {
+			delete(a.days, date)
+		}
+	}
+
+	covered := a.covered[:0]
+	for _, r := range a.covered {
+		if !r.from.Before(since) {
+			continue
+		}
+		if !r.to.Before(since) {
+			r.to = since.AddDays(-1)
+		}
+		covered = append(covered, r)
+	}
+	a.covered = covered
+}
+
+// listDaysCached lists the days of a real asset within [from, to],
+// only requesting the parts of the range missing from the client's
+// day cache.
+func (s *RealAssetsService) listDaysCached(ctx context.Context, id string, from, to Date) ([]*RealAssetDay, *Response, error) {
+	dc := s.client.days
+	start := time.Now()
+
+	var resps []*Response
+	for _, gap := range dc.missing(id, from, to) {
+		days, r, err := s.fetchDays(ctx, id, gap.from, gap.to)
+		if err != nil {
+			return nil, r, err
+		}
+		dc.add(id, gap.from, gap.to, days)
+		resps = append(resps, r)
+	}
+
+	if len(resps) == 0 {
+		if s.client.metrics != nil {
+			s.client.metrics.IncCacheHits("/real_assets/:id/days")
+		}
+		return dc.get(id, from, to), &Response{Response: cachedHTTPResponse(nil, nil), Cached: true}, nil
+	}
+	return dc.get(id, from, to), combineResponses(start, resps), nil
+}
+
+// cachedHTTPResponse returns a successful HTTP response
+// for a request served from a cache.
+func cachedHTTPResponse(req *http.Request, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
@@ -0,0 +1,196 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// parseRanges parses ranges written as "from/to".
+func parseRanges(t *testing.T, ss ...string) []dateRange {
+	t.Helper()
+
+	var ranges []dateRange
+	for _, s := range ss {
+		ranges = append(ranges, dateRange{mustParseDate(t, s[:10]), mustParseDate(t, s[11:])})
+	}
+	return ranges
+}
+
+func formatRanges(ranges []dateRange) []string {
+	var ss []string
+	for _, r := range ranges {
+		ss = append(ss, r.from.String()+"/"+r.to.String())
+	}
+	return ss
+}
+
+func TestDayCache_missing(t *testing.T) {
+	tests := []struct {
+		name    string
+		covered []string
+		query   string
+		want    []string
+	}{
+		{"nothing cached", nil, "2020-01-01/2020-01-31", []string{"2020-01-01/2020-01-31"}},
+		{"fully covered", []string{"2020-01-01/2020-01-31"}, "2020-01-05/2020-01-20", nil},
+		{"exactly covered", []string{"2020-01-01/2020-01-31"}, "2020-01-01/2020-01-31", nil},
+		{"covered inside", []string{"2020-01-10/2020-01-20"}, "2020-01-01/2020-01-31", []string{"2020-01-01/2020-01-09", "2020-01-21/2020-01-31"}},
+		{"overlapping start", []string{"2019-12-01/2020-01-10"}, "2020-01-01/2020-01-31", []string{"2020-01-11/2020-01-31"}},
+		{"overlapping end", []string{"2020-01-20/2020-02-10"}, "2020-01-01/2020-01-31", []string{"2020-01-01/2020-01-19"}},
+		{"adjacent before", []string{"2019-12-01/2019-12-31"}, "2020-01-01/2020-01-31", []string{"2020-01-01/2020-01-31"}},
+		{"adjacent after", []string{"2020-02-01/2020-02-10"}, "2020-01-01/2020-01-31", []string{"2020-01-01/2020-01-31"}},
+		{"disjoint ranges", []string{"2020-01-03/2020-01-05", "2020-01-10/2020-01-12", "2020-01-30/2020-02-05"}, "2020-01-01/2020-01-31",
+			[]string{"2020-01-01/2020-01-02", "2020-01-06/2020-01-09", "2020-01-13/2020-01-29"}},
+		{"single day gap", []string{"2020-01-01/2020-01-09", "2020-01-11/2020-01-31"}, "2020-01-01/2020-01-31", []string{"2020-01-10/2020-01-10"}},
+	}
+
+	for _, tt := range tests {
+		dc := NewDayCache()
+		if tt.covered != nil {
+			dc.assets["186"] = &assetDays{days: make(map[Date]*RealAssetDay), covered: parseRanges(t, tt.covered...)}
+		}
+		q := parseRanges(t, tt.query)[0]
+
+		if got := formatRanges(dc.missing("186", q.from, q.to)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: missing(%s) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestAssetDays_cover(t *testing.T) {
+	tests := []struct {
+		name    string
+		covered []string
+		add     string
+		want    []string
+	}{
+		{"first range", nil, "2020-01-01/2020-01-10", []string{"2020-01-01/2020-01-10"}},
+		{"disjoint", []string{"2020-01-01/2020-01-10"}, "2020-01-20/2020-01-31", []string{"2020-01-01/2020-01-10", "2020-01-20/2020-01-31"}},
+		{"adjacent", []string{"2020-01-01/2020-01-10"}, "2020-01-11/2020-01-20", []string{"2020-01-01/2020-01-20"}},
+		{"overlapping", []string{"2020-01-01/2020-01-10"}, "2020-01-05/2020-01-20", []string{"2020-01-01/2020-01-20"}},
+		{"contained", []string{"2020-01-01/2020-01-31"}, "2020-01-05/2020-01-20", []string{"2020-01-01/2020-01-31"}},
+		{"bridging", []string{"2020-01-01/2020-01-10", "2020-01-20/2020-01-31"}, "2020-01-11/2020-01-19", []string{"2020-01-01/2020-01-31"}},
+		{"before", []string{"2020-01-20/2020-01-31"}, "2020-01-01/2020-01-05", []string{"2020-01-01/2020-01-05", "2020-01-20/2020-01-31"}},
+	}
+
+	for _, tt := range tests {
+		a := &assetDays{covered: parseRanges(t, tt.covered...)}
+		a.cover(parseRanges(t, tt.add)[0])
+
+		if got := formatRanges(a.covered); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: cover(%s) gave %v, want %v", tt.name, tt.add, got, tt.want)
+		}
+	}
+}
+
+// daysOf returns a day for every date from from to to.
+func daysOf(from, to Date) []*RealAssetDay {
+	var days []*RealAssetDay
+	for d := from; !d.After(to); d = d.AddDays(1) {
+		days = append(days, &RealAssetDay{Attributes: RealAssetDayAttributes{Date: d}})
+	}
+	return days
+}
+
+func TestDayCache_addSkipsUnsettledDays(t *testing.T) {
+	dc := NewDayCache()
+	from, to := today().AddDays(-10), today()
+	dc.add("186", from, to, daysOf(from, to))
+
+	want := []string{from.String() + "/" + today().AddDays(-unsettledDays).String()}
+	if got := formatRanges(dc.assets["186"].covered); !reflect.DeepEqual(got, want) {
+		t.Errorf("covered %v, want %v", got, want)
+	}
+	if got := len(dc.get("186", from, to)); got != 11 {
+		t.Errorf("got %d cached days, want 11", got)
+	}
+}
+
+func TestDayCache_InvalidateLastDays(t *testing.T) {
+	dc := NewDayCache()
+	from, to := today().AddDays(-30), today().AddDays(-5)
+	dc.add("186", from, to, daysOf(from, to))
+
+	dc.InvalidateLastDays(10)
+
+	since := today().AddDays(-9)
+	want := []string{from.String() + "/" + since.AddDays(-1).String()}
+	if got := formatRanges(dc.assets["186"].covered); !reflect.DeepEqual(got, want) {
+		t.Errorf("covered %v, want %v", got, want)
+	}
+	days := dc.get("186", from, to)
+	if len(days) != 21 || !days[len(days)-1].Attributes.Date.Before(since) {
+		t.Errorf("got %d days ending on %s, want 21 days before %s", len(days), days[len(days)-1].Attributes.Date, since)
+	}
+	if got, want := formatRanges(dc.missing("186", from, to)), []string{since.String() + "/" + to.String()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing %v, want %v", got, want)
+	}
+}
+
+func TestDayCache_Invalidate(t *testing.T) {
+	dc := NewDayCache()
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-10")
+	dc.add("186", from, to, daysOf(from, to))
+
+	dc.Invalidate("186")
+	if days := dc.get("186", from, to); days != nil {
+		t.Errorf("got %d days after Invalidate, want none", len(days))
+	}
+}
+
+func TestListDaysByDates_requestsOnlyMissingDays(t *testing.T) {
+	h := &daysHandler{}
+	c := setup(t, h, WithDayCache(NewDayCache()))
+
+	first, second := parseRanges(t, "2020-01-01/2020-01-10")[0], parseRanges(t, "2020-01-05/2020-01-20")[0]
+	if _, err := c.RealAssets.ListDaysByDates(context.Background(), "186", first.from, first.to); err != nil {
+		t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+	}
+	days, resp, err := c.RealAssets.ListDaysByDatesWithResponse(context.Background(), "186", second.from, second.to)
+	if err != nil {
+		t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+	}
+
+	if want := []string{"2020-01-01/2020-01-10", "2020-01-11/2020-01-20"}; !reflect.DeepEqual(h.ranges, want) {
+		t.Errorf("requested ranges %v, want %v", h.ranges, want)
+	}
+	if len(days) != 16 || days[0].Attributes.Date != second.from || days[15].Attributes.Date != second.to {
+		t.Errorf("got %d days, want the 16 days from %s to %s", len(days), second.from, second.to)
+	}
+	if resp.Cached || resp.Attempts != 1 {
+		t.Errorf("partially cached range returned cached %v and %d attempts, want not cached and 1 attempt", resp.Cached, resp.Attempts)
+	}
+
+	if _, resp, err = c.RealAssets.ListDaysByDatesWithResponse(context.Background(), "186", first.from, second.to); err != nil || !resp.Cached {
+		t.Errorf("fully cached range returned error %v, cached %v", err, resp.Cached)
+	}
+	if len(h.ranges) != 2 {
+		t.Errorf("fully cached range made %d more requests", len(h.ranges)-2)
+	}
+}
+
+func TestListDaysByDatesWithResponse_coversEveryGap(t *testing.T) {
+	h := &daysHandler{}
+	c := setup(t, h, WithDayCache(NewDayCache()))
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-20")
+	if _, err := c.RealAssets.ListDaysByDates(context.Background(), "186", from.AddDays(5), to.AddDays(-5)); err != nil {
+		t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+	}
+	days, resp, err := c.RealAssets.ListDaysByDatesWithResponse(context.Background(), "186", from, to)
+	if err != nil {
+		t.Fatalf("RealAssets.ListDaysByDatesWithResponse returned error: %v", err)
+	}
+
+	if len(h.ranges) != 3 {
+		t.Errorf("requested ranges %v, want the two gaps around the cached days", h.ranges)
+	}
+	if len(days) != 20 {
+		t.Errorf("got %d days, want 20", len(days))
+	}
+	if resp.StatusCode != http.StatusOK || resp.Cached || resp.Attempts != 2 {
+		t.Errorf("got status %d, cached %v and %d attempts, want 200, not cached and 2 attempts", resp.StatusCode, resp.Cached, resp.Attempts)
+	}
+}
@@ -0,0 +1,85 @@
+package fintual
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Sentinel errors which can be matched against errors returned by
+// the client with errors.Is.
+var (
+	ErrBadRequest       = errors.New("fintual: bad request")
+	ErrUnauthorized     = errors.New("fintual: unauthorized")
+	ErrForbidden        = errors.New("fintual: forbidden")
+	ErrNotFound         = errors.New("fintual: not found")
+	ErrRateLimited      = errors.New("fintual: rate limited")
+	ErrServer           = errors.New("fintual: server error")
+	ErrNotAuthenticated = errors.New("fintual: client not authenticated, call Client.Authenticate with valid credentials")
+)
+
+// Error represents an error returned by the Fintual API.
+// Use errors.As to retrieve it from an error returned by the client.
+type Error struct {
+	Code    int    `json:"code"`    // The HTTP status code.
+	Status  string `json:"status"`  // The HTTP response status (error or success).
+	Message string `json:"message"` // A short description of the error.
+
+	HTTPStatus int    `json:"-"` // The status code of the HTTP response.
+	Method     string `json:"-"` // The method of the failed request.
+	URL        string `json:"-"` // The URL of the failed request.
+	Body       []byte `json:"-"` // The raw body of the HTTP response.
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.HTTPStatus)
+	}
+	return fmt.Sprintf("fintual: %s %s: %d %s", e.Method, e.URL, e.HTTPStatus, msg)
+}
+
+// Is reports whether e matches one of the sentinel errors of this package,
+// based on the HTTP status of the response.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.HTTPStatus == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.HTTPStatus == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.HTTPStatus == http.StatusForbidden
+	case ErrNotFound:
+		return e.HTTPStatus == http.StatusNotFound
+	case ErrRateLimited:
+		return e.HTTPStatus == http.StatusTooManyRequests
+	case ErrServer:
+		return e.HTTPStatus >= 500
+	}
+	return false
+}
+
+// decodeError decodes an error from an HTTP response. The returned
+// error is always an *Error, unless reading the body fails.
+func (c *Client) decodeError(resp *http.Response) error {
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	e := &Error{HTTPStatus: resp.StatusCode, Body: respBody}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = redactURL(resp.Request.URL)
+	}
+
+	if len(respBody) > 0 {
+		// The body is kept as is when it is not a JSON API error.
+		_ = json.NewDecoder(bytes.NewReader(respBody)).Decode(e)
+	}
+
+	return e
+}
@@ -0,0 +1,77 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestError_Is(t *testing.T) {
+	sentinels := []error{ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited, ErrServer}
+	tests := []struct {
+		status int
+		want   error // nil if the error matches no sentinel
+	}{
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusBadGateway, ErrServer},
+		{http.StatusServiceUnavailable, ErrServer},
+		{http.StatusConflict, nil},
+		{http.StatusUnprocessableEntity, nil},
+	}
+
+	for _, tt := range tests {
+		err := error(&Error{HTTPStatus: tt.status})
+		for _, s := range sentinels {
+			if got := errors.Is(err, s); got != (s == tt.want) {
+				t.Errorf("errors.Is(%d error, %v) = %v, want %v", tt.status, s, got, !got)
+			}
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+		wantError   string
+	}{
+		{"API error", http.StatusUnprocessableEntity, `{"code":422,"status":"error","message":"invalid dates"}`, "invalid dates", "422 invalid dates"},
+		{"non-JSON body", http.StatusBadGateway, `<html>Bad Gateway</html>`, "", "502 Bad Gateway"},
+		{"empty body", http.StatusForbidden, ``, "", "403 Forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+
+			_, err := c.Banks.ListAll(context.Background(), nil)
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Banks.ListAll returned error %v, want an *Error", err)
+			}
+			if apiErr.HTTPStatus != tt.status || apiErr.Message != tt.wantMessage || string(apiErr.Body) != tt.body {
+				t.Errorf("got status %d, message %q and body %q, want %d, %q and %q",
+					apiErr.HTTPStatus, apiErr.Message, apiErr.Body, tt.status, tt.wantMessage, tt.body)
+			}
+			if apiErr.Method != "GET" || !strings.HasSuffix(apiErr.URL, banksEndpoint) {
+				t.Errorf("error is for %s %s, want GET %s", apiErr.Method, apiErr.URL, banksEndpoint)
+			}
+			want := "fintual: GET " + apiErr.URL + ": " + tt.wantError
+			if apiErr.Error() != want {
+				t.Errorf("Error() = %q, want %q", apiErr.Error(), want)
+			}
+		})
+	}
+}
@@ -7,9 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -19,10 +17,12 @@ import (
 )
 
 // Version is the version of this library.
-const Version = "1.0.0"
+const Version = "1.1.0"
 
 const (
-	baseURL = "https://fintual.cl/api"
+	baseURL          = "https://fintual.cl/api"
+	defaultUserAgent = "go-fintual/" + Version
+	defaultTimeout   = time.Minute
 )
 
 type service struct {
@@ -30,10 +30,29 @@ type service struct {
 }
 
 type Client struct {
-	http        *http.Client // HTTP client used to communicate with the API.
-	baseURL     *url.URL     // Base URL for API requests
-	userEmail   string       // User's email used for methods which require authentication
-	accessToken string       // Access token used for methods which require authentication
+	http        *http.Client        // HTTP client used to communicate with the API.
+	middlewares []Middleware        // Middlewares wrapping http
+	doer        Doer                // Chain of middlewares used for sending requests
+	baseURL     *url.URL            // Base URL for API requests
+	userAgent   string              // User agent used when communicating with the API
+	headers     http.Header         // Headers sent with every request
+	timeout     time.Duration       // Time limit for requests, overrides the one of http if set
+	session     *sessionState       // Credentials used for methods which require authentication
+	authMethod  AuthMethod          // How authentication credentials are sent to the API
+	tokens      TokenStore          // Store used for persisting sessions
+	credentials CredentialsProvider // Provider of credentials used for re-authenticating
+	retry       *RetryPolicy        // Policy used for retrying failed requests
+	limiter     *RateLimiter        // Rate limiter every request waits on
+	logger      Logger              // Logger receiving every request in debug mode
+	tracer      Tracer              // Tracer starting spans around operations and attempts
+	metrics     MetricsCollector    // Collector of metrics about requests
+	cache       *responseCache      // Cache of unauthenticated GET responses
+	days        *DayCache           // Cache of real asset days
+	flights     *flightGroup        // In-flight GET requests shared by callers
+	breaker     *CircuitBreaker     // Circuit breaker failing requests fast during outages
+	batchLimit  int                 // Maximum number of concurrent requests made by batch methods
+	batchSem    chan struct{}       // Slots for batch and window requests, shared by every call
+	daysWindow  int                 // Maximum number of days requested at once by ListDaysByDates
 
 	// Services used for talking to different parts of the Fintual API.
 	AssetProviders   *AssetProvidersService
@@ -49,29 +68,60 @@ type Client struct {
 // To use API methods which require authentication, you must call
 // the Client.Authenticate method with valid credentials.
 func NewClient(httpClient *http.Client) *Client {
-	if httpClient == nil {
-		httpClient = &http.Client{Timeout: time.Minute}
-	}
+	c, _ := New(WithHTTPClient(httpClient))
+	return c
+}
+
+// New returns a new Fintual API client configured with the given options.
+//
+// To use API methods which require authentication, you must call
+// the Client.Authenticate method with valid credentials.
+func New(opts ...Option) (*Client, error) {
 	baseURL, _ := url.Parse(baseURL)
 
-	c := &Client{http: httpClient, baseURL: baseURL}
+	c := &Client{
+		http:       &http.Client{Timeout: defaultTimeout},
+		baseURL:    baseURL,
+		userAgent:  defaultUserAgent,
+		headers:    make(http.Header),
+		session:    &sessionState{},
+		flights:    &flightGroup{flights: make(map[string]*flight)},
+		batchLimit: defaultBatchConcurrency,
+		daysWindow: defaultDaysWindow,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
 
+	httpClient := *c.http
+	if c.timeout > 0 {
+		httpClient.Timeout = c.timeout
+	}
+	httpClient.CheckRedirect = checkRedirect(c.http.CheckRedirect)
+	c.http = &httpClient
+	mws := c.middlewares
+	if c.logger != nil {
+		mws = append(mws[:len(mws):len(mws)], LoggingMiddleware(c.logger))
+	}
+	c.doer = chain(c.http, mws)
+	c.batchSem = make(chan struct{}, c.batchLimit)
+	if c.cache != nil && c.cache.store == nil {
+		c.cache = nil
+	}
+
+	c.initServices()
+	return c, nil
+}
+
+// initServices creates the services of the client.
+func (c *Client) initServices() {
 	c.AssetProviders = &AssetProvidersService{client: c}
 	c.Banks = &BanksService{client: c}
 	c.ConceptualAssets = &ConceptualAssetsService{client: c}
 	c.Goals = &GoalsService{client: c}
 	c.RealAssets = &RealAssetsService{client: c}
-	return c
-}
-
-// setAccessToken sets the given token to the current Fintual client.
-func (c *Client) setAccessToken(token string) {
-	c.accessToken = token
-}
-
-// setUserEmail sets the given email to the current Fintual client.
-func (c *Client) setUserEmail(token string) {
-	c.userEmail = token
 }
 
 // addParams adds the parameters in params as URL query parameters to s. params
@@ -96,33 +146,6 @@ func addParams(s string, params interface{}) (string, error) {
 	return u.String(), nil
 }
 
-// Error represents an error returned by the Fintual API.
-type Error struct {
-	Code    int    `json:"code"`    // The HTTP status code.
-	Status  string `json:"status"`  // The HTTP response status (error or success).
-	Message string `json:"message"` // A short description of the error.
-}
-
-// decodeError decodes an error from an io.Reader.
-func (c *Client) decodeError(resp *http.Response) error {
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	if len(respBody) == 0 {
-		return fmt.Errorf("HTTP %d: %s (body empty)", resp.StatusCode, http.StatusText(resp.StatusCode))
-	}
-
-	buf := bytes.NewBuffer(respBody)
-	var e Error
-	err = json.NewDecoder(buf).Decode(&e)
-	if err != nil {
-		return fmt.Errorf("couldn't decode error: [%s]", respBody)
-	}
-
-	return fmt.Errorf("error %v: %s ", e.Code, e.Message)
-}
-
 // newRequest creates a new API request with context. If specified,
 // the value pointed to by body is JSON encoded and included in the request body.
 func (c *Client) newRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
@@ -145,87 +168,237 @@ func (c *Client) newRequest(ctx context.Context, method, url string, body interf
 	}
 
 	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	for k, v := range c.headers {
+		req.Header[k] = append([]string(nil), v...)
+	}
 
 	return req, nil
 }
 
 // send makes a request to the API, the response body will be
-// unmarshalled into v.
-func (c *Client) send(req *http.Request, v interface{}) error {
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return err
+// unmarshalled into v. Failed attempts are retried according to
+// the client's retry policy.
+func (c *Client) send(req *http.Request, v interface{}) (*Response, error) {
+	resp, attempts, err := c.sendWithRetries(req, v)
+	if op := operationFromContext(req.Context()); op != nil {
+		op.record(resp, attempts, err)
+	}
+	return resp, err
+}
+
+// sendWithRetries makes attempts of req until one succeeds or may not
+// be retried. It returns the number of attempts made.
+func (c *Client) sendWithRetries(req *http.Request, v interface{}) (*Response, int, error) {
+	ctx := req.Context()
+	retry := c.retry.allows(req)
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, attempt - 1, err
+			}
+		}
+		var gen int
+		if c.breaker != nil {
+			var err error
+			if gen, err = c.breaker.allow(); err != nil {
+				return nil, attempt - 1, err
+			}
+		}
+
+		resp, err := c.do(req, attempt)
+		if c.breaker != nil {
+			c.breaker.observe(ctx, gen, resp, err)
+		}
+		if !retry || attempt >= c.retry.MaxAttempts || !c.retry.shouldRetry(ctx, resp, err) {
+			if err != nil {
+				return nil, attempt, err
+			}
+			r := &Response{Response: resp, Attempts: attempt}
+			err = c.handleResponse(req, resp, v)
+			r.Duration = time.Since(start)
+			return r, attempt, err
+		}
+
+		wait := c.retry.backoff(attempt, resp)
+		if resp != nil {
+			discard(resp)
+		}
+		if c.metrics != nil {
+			c.metrics.IncRetries(endpointOf(req), req.Method)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, attempt, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, attempt, err
+		}
+	}
+}
+
+// do makes a single attempt of req through the client's middlewares.
+func (c *Client) do(req *http.Request, attempt int) (*http.Response, error) {
+	start := time.Now()
+	req = req.WithContext(context.WithValue(req.Context(), attemptKey{}, attempt))
+
+	var span Span
+	if c.tracer != nil {
+		var ctx context.Context
+		ctx, span = c.tracer.Start(req.Context(), "HTTP "+req.Method,
+			attr(attrMethod, req.Method),
+			attr(attrURL, redactURL(req.URL)),
+			attr(attrResendCount, attempt-1),
+		)
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := c.doer.Do(req)
+	err = redactError(err)
+
+	if c.metrics != nil {
+		m := RequestMetrics{Endpoint: endpointOf(req), Method: req.Method, Duration: time.Since(start), Err: err}
+		if resp != nil {
+			m.Status = resp.StatusCode
+		}
+		c.metrics.ObserveRequest(m)
 	}
 
+	if span != nil {
+		if resp != nil {
+			span.SetAttributes(attr(attrStatusCode, resp.StatusCode))
+		}
+		span.End(err)
+	}
+	if c.limiter != nil && resp != nil {
+		c.limiter.observe(resp)
+	}
+	return resp, err
+}
+
+// handleResponse checks the status of resp and unmarshals its body into v.
+// A 304 (Not Modified) response to a conditional request is not an error,
+// and its empty body is left for the caller to replace.
+func (c *Client) handleResponse(req *http.Request, resp *http.Response, v interface{}) error {
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && isConditional(req) {
+		return nil
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		return c.decodeError(resp)
 	}
 
-	if resp.StatusCode == http.StatusNoContent {
+	if resp.StatusCode == http.StatusNoContent || v == nil {
 		return nil
 	}
 
+	if d, ok := v.(bodyDecoder); ok {
+		return d.decode(resp.Body)
+	}
 	return json.NewDecoder(resp.Body).Decode(v)
 }
 
+// isConditional reports whether req is a conditional request.
+func isConditional(req *http.Request) bool {
+	return req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+}
+
 // get makes a GET request to the given url. The response body will be
-// unmarshalled into v.
-func (c *Client) get(ctx context.Context, url string, v interface{}) error {
+// unmarshalled into v. Identical concurrent requests share a single
+// request to the API.
+func (c *Client) get(ctx context.Context, url string, v interface{}) (*Response, error) {
 	req, err := c.newRequest(ctx, "GET", url, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = c.send(req, v)
-	if err != nil {
-		return err
+	resp, body, err := c.flights.do(req, c.getRaw)
+	if err != nil || len(body) == 0 {
+		return resp, err
+	}
+
+	return resp, json.Unmarshal(body, v)
+}
+
+// getRaw makes the GET request req through the client's response cache,
+// if any, and returns the raw body of the response.
+func (c *Client) getRaw(req *http.Request) (*Response, []byte, error) {
+	var body json.RawMessage
+	if c.cache != nil {
+		if ttl, ok := c.cache.ttlOf(endpointOf(req)); ok {
+			resp, err := c.getCached(req, ttl, &body)
+			return resp, body, err
+		}
 	}
 
-	return nil
+	resp, err := c.send(req, &body)
+	return resp, body, err
 }
 
 // post makes a POST request to the given url. The response body will be
 // unmarshalled into v.
-func (c *Client) post(ctx context.Context, url string, body, v interface{}) error {
+func (c *Client) post(ctx context.Context, url string, body, v interface{}) (*Response, error) {
 	req, err := c.newRequest(ctx, "POST", url, body)
 	if err != nil {
-		return err
-	}
-
-	err = c.send(req, v)
-	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return c.send(req, v)
 }
 
 // getWithAuth makes a GET request with authentication credentials
 // to the given url. The response body will be unmarshalled into v.
-func (c *Client) getWithAuth(ctx context.Context, url string, v interface{}) error {
-	if c.accessToken == "" || c.userEmail == "" {
-		return errors.New("client not authenticated, call Client.Authenticate with valid credentials")
+func (c *Client) getWithAuth(ctx context.Context, url string, v interface{}) (*Response, error) {
+	return c.sendWithAuth(ctx, "GET", url, nil, v)
+}
+
+// sendWithAuth makes a request with authentication credentials to the given
+// url. If the client has a CredentialsProvider, it authenticates when there
+// is no session and once again when the API rejects the current one. A
+// rejected session loaded from the TokenStore by Authenticate is replaced
+// by logging in once with the password given to Authenticate.
+func (c *Client) sendWithAuth(ctx context.Context, method, url string, body, v interface{}) (*Response, error) {
+	session := c.Session()
+	if !session.valid() {
+		if c.credentials == nil {
+			return nil, ErrNotAuthenticated
+		}
+		if err := c.reauthenticate(ctx, session); err != nil {
+			return nil, err
+		}
+		session = c.Session()
 	}
 
-	req, err := c.newRequest(ctx, "GET", url, nil)
-	if err != nil {
-		return err
+	resp, err := c.sendAs(ctx, session, method, url, body, v)
+	if !errors.Is(err, ErrUnauthorized) {
+		return resp, err
+	}
+	if c.credentials == nil && !c.session.canLogin(session) {
+		return resp, err
 	}
 
-	q := req.URL.Query()
-	q.Add("user_email", c.userEmail)
-	q.Add("user_token", c.accessToken)
-	req.URL.RawQuery = q.Encode()
+	if err := c.reauthenticate(ctx, session); err != nil {
+		return resp, err
+	}
+	return c.sendAs(ctx, c.Session(), method, url, body, v)
+}
 
-	err = c.send(req, v)
+// sendAs makes a request with the credentials of the given session.
+func (c *Client) sendAs(ctx context.Context, session Session, method, url string, body, v interface{}) (*Response, error) {
+	req, err := c.newRequest(ctx, method, url, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	c.setAuth(req, session.Email, session.Token)
+
+	return c.send(req, v)
 }
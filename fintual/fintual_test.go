@@ -0,0 +1,96 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// setup returns a client sending its requests to a test server
+// which serves them with handler.
+func setup(t *testing.T, handler http.Handler, opts ...Option) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := New(append([]Option{WithBaseURL(srv.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return c
+}
+
+// testTracer is a Tracer recording the spans it starts. Like OpenTelemetry,
+// it propagates spans through the context, so that a span started with the
+// context of another one is its child.
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+	ends  int // Number of spans ended
+}
+
+type testSpan struct {
+	id     int // Position of the span in testTracer.spans, starting at 1
+	parent int // ID of the parent span, zero for a root span
+	name   string
+	attrs  map[string]interface{}
+	ended  int // Position of the span among the ended spans, starting at 1
+	err    error
+}
+
+type testSpanKey struct{}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := &testSpan{id: len(t.spans) + 1, name: name, attrs: make(map[string]interface{})}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		s.parent = parent.id
+	}
+	t.spans = append(t.spans, s)
+	s.setAttributes(attrs)
+	return context.WithValue(ctx, testSpanKey{}, s), &testSpanHandle{t: t, s: s}
+}
+
+// named returns the spans with the given name.
+func (t *testTracer) named(name string) []testSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var spans []testSpan
+	for _, s := range t.spans {
+		if s.name == name {
+			spans = append(spans, *s)
+		}
+	}
+	return spans
+}
+
+func (s *testSpan) setAttributes(attrs []Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+// testSpanHandle is a Span whose methods lock the tracer it belongs to.
+type testSpanHandle struct {
+	t *testTracer
+	s *testSpan
+}
+
+func (h *testSpanHandle) SetAttributes(attrs ...Attribute) {
+	h.t.mu.Lock()
+	defer h.t.mu.Unlock()
+	h.s.setAttributes(attrs)
+}
+
+func (h *testSpanHandle) End(err error) {
+	h.t.mu.Lock()
+	defer h.t.mu.Unlock()
+	h.t.ends++
+	h.s.ended, h.s.err = h.t.ends, err
+}
@@ -0,0 +1,90 @@
+// Package fintualprom adapts the metrics collected by a
+// fintual.Client to Prometheus.
+package fintualprom
+
+import (
+	"strconv"
+
+	"github.com/ferueda/go-fintual/fintual"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "fintual"
+
+// Collector is a fintual.MetricsCollector which records
+// metrics in Prometheus counters and histograms.
+type Collector struct {
+	requests  *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	retries   *prometheus.CounterVec
+	cacheHits *prometheus.CounterVec
+}
+
+// New returns a Collector whose metrics are registered with reg.
+// If reg is nil, prometheus.DefaultRegisterer is used.
+func New(reg prometheus.Registerer) (*Collector, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	c := &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of requests made to the Fintual API, by endpoint, method and status code.",
+		}, []string{"endpoint", "method", "status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "request_errors_total",
+			Help:      "Number of failed requests made to the Fintual API, by endpoint, method and status code.",
+		}, []string{"endpoint", "method", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests made to the Fintual API, by endpoint and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "request_retries_total",
+			Help:      "Number of retried requests made to the Fintual API, by endpoint and method.",
+		}, []string{"endpoint", "method"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of responses served from a cache, by endpoint.",
+		}, []string{"endpoint"}),
+	}
+
+	for _, m := range []prometheus.Collector{c.requests, c.errors, c.latency, c.retries, c.cacheHits} {
+		if err := reg.Register(m); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// ObserveRequest implements fintual.MetricsCollector.
+func (c *Collector) ObserveRequest(m fintual.RequestMetrics) {
+	status := "error"
+	if m.Status != 0 {
+		status = strconv.Itoa(m.Status)
+	}
+
+	c.requests.WithLabelValues(m.Endpoint, m.Method, status).Inc()
+	c.latency.WithLabelValues(m.Endpoint, m.Method).Observe(m.Duration.Seconds())
+	if m.Err != nil || m.Status >= 400 {
+		c.errors.WithLabelValues(m.Endpoint, m.Method, status).Inc()
+	}
+}
+
+// IncRetries implements fintual.MetricsCollector.
+func (c *Collector) IncRetries(endpoint, method string) {
+	c.retries.WithLabelValues(endpoint, method).Inc()
+}
+
+// IncCacheHits implements fintual.MetricsCollector.
+func (c *Collector) IncCacheHits(endpoint string) {
+	c.cacheHits.WithLabelValues(endpoint).Inc()
+}
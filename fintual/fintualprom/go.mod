@@ -0,0 +1,30 @@
+// fintualprom is a separate module so that only its users depend on the
+// Prometheus client. It requires v1.1.0, the first release of go-fintual
+// with MetricsCollector.
+module github.com/ferueda/go-fintual/fintual/fintualprom
+
+go 1.17
+
+require (
+	github.com/ferueda/go-fintual v1.1.0
+	github.com/prometheus/client_golang v1.11.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.1 // indirect
+	github.com/golang/protobuf v1.4.3 // indirect
+	github.com/google/go-querystring v1.1.0 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/client_model v0.2.0 // indirect
+	github.com/prometheus/common v0.26.0 // indirect
+	github.com/prometheus/procfs v0.6.0 // indirect
+	golang.org/x/sys v0.0.0-20210603081109-ebe580a85c40 // indirect
+	google.golang.org/protobuf v1.26.0-rc.1 // indirect
+)
+
+// Temporary, until go-fintual v1.1.0 is tagged: builds within this
+// repository use the local module. Consumers ignore this directive.
+// The root module is tagged first, then this one; see Releasing in
+// the README.
+replace github.com/ferueda/go-fintual v1.1.0 => ../..
@@ -3,6 +3,7 @@ package fintual
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 const (
@@ -25,28 +26,28 @@ type GoalAttributes struct {
 	Name                   string       `json:"name"`
 	NameWithoutSuffix      string       `json:"name_without_suffix"`
 	NetAssetValue          float64      `json:"nav"`
-	CreatedAt              string       `json:"created_at"`
+	CreatedAt              time.Time    `json:"created_at"`
 	Timeframe              int          `json:"timeframe"`
 	Deposited              float64      `json:"deposited"`
 	Hidden                 bool         `json:"hidden"`
 	Profit                 float64      `json:"profit"`
 	Investments            []Investment `json:"investments"`
-	PublicLink             interface{}  `json:"public_link"`
+	PublicLink             *string      `json:"public_link"`
 	ParamID                int64        `json:"param_id"`
 	GoalType               string       `json:"goal_type"`
 	TranslatedGoalType     string       `json:"translated_goal_type"`
-	Regime                 interface{}  `json:"regime"`
+	Regime                 *string      `json:"regime"`
 	Completed              bool         `json:"completed"`
 	HasAnyWithdrawals      bool         `json:"has_any_withdrawals"`
 	EligibleForDeposits    bool         `json:"eligible_for_deposits"`
 	EligibleForInternalMlt bool         `json:"eligible_for_internal_mlt"`
 	MonthlyDeposit         float64      `json:"monthly_deposit"`
 	SimulatedDeposit       float64      `json:"simulated_deposit"`
-	FundsSource            interface{}  `json:"funds_source"`
-	FundsSourceDescription interface{}  `json:"funds_source_description"`
+	FundsSource            *string      `json:"funds_source"`
+	FundsSourceDescription *string      `json:"funds_source_description"`
 	NotNetDeposited        float64      `json:"not_net_deposited"`
 	Withdrawn              float64      `json:"withdrawn"`
-	GroupGoalID            interface{}  `json:"group_goal_id"`
+	GroupGoalID            *int64       `json:"group_goal_id"` // Nil if the goal is not part of a group
 }
 
 type Investment struct {
@@ -55,37 +56,59 @@ type Investment struct {
 }
 
 // ListAll lists all Goals for the authenticated user.
-// Requires authentication by calling Client.Authenticate.
+// Requires authentication by calling Client.Authenticate
+// or configuring the client with a CredentialsProvider.
 //
 // Endpoint: GET /goals
 func (s *GoalsService) ListAll(ctx context.Context) ([]*Goal, error) {
+	g, _, err := s.ListAllWithResponse(ctx)
+	return g, err
+}
+
+// ListAllWithResponse is like ListAll, but it also returns
+// the response of the API.
+func (s *GoalsService) ListAllWithResponse(ctx context.Context) ([]*Goal, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "Goals", "ListAll", "/goals")
+	defer op.end()
+
 	url := s.client.baseURL.String() + goalsEndpoint
 	var g struct {
 		Data []*Goal `json:"data"`
 	}
 
-	err := s.client.getWithAuth(ctx, url, &g)
+	resp, err := s.client.getWithAuth(ctx, url, &g)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return g.Data, nil
+	return g.Data, resp, nil
 }
 
 // Get retrieves a specific goal.
-// Requires authentication by calling Client.Authenticate.
+// Requires authentication by calling Client.Authenticate
+// or configuring the client with a CredentialsProvider.
 //
 // Endpoint: GET /goals/:id
 func (s *GoalsService) Get(ctx context.Context, id string) (*Goal, error) {
+	g, _, err := s.GetWithResponse(ctx, id)
+	return g, err
+}
+
+// GetWithResponse is like Get, but it also returns
+// the response of the API.
+func (s *GoalsService) GetWithResponse(ctx context.Context, id string) (*Goal, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "Goals", "Get", "/goals/:id", attr(attrResourceID, id))
+	defer op.end()
+
 	url := fmt.Sprintf("%s/%s", s.client.baseURL.String()+goalsEndpoint, id)
 	var g struct {
 		Data *Goal `json:"data"`
 	}
 
-	err := s.client.getWithAuth(ctx, url, &g)
+	resp, err := s.client.getWithAuth(ctx, url, &g)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return g.Data, nil
+	return g.Data, resp, nil
 }
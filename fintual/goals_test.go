@@ -0,0 +1,49 @@
+package fintual
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGoalAttributes_nullableFields(t *testing.T) {
+	var null GoalAttributes
+	if err := json.Unmarshal([]byte(`{
+		"public_link": null,
+		"regime": null,
+		"funds_source": null,
+		"funds_source_description": null,
+		"group_goal_id": null
+	}`), &null); err != nil {
+		t.Fatalf("decoding null fields returned error: %v", err)
+	}
+	if null.PublicLink != nil || null.Regime != nil || null.FundsSource != nil || null.FundsSourceDescription != nil || null.GroupGoalID != nil {
+		t.Errorf("null fields decoded as %+v, want nil pointers", null)
+	}
+
+	var set GoalAttributes
+	if err := json.Unmarshal([]byte(`{
+		"public_link": "https://fintual.cl/g/abc",
+		"regime": "APV-A",
+		"funds_source": "salary",
+		"funds_source_description": "Monthly salary",
+		"group_goal_id": 42
+	}`), &set); err != nil {
+		t.Fatalf("decoding set fields returned error: %v", err)
+	}
+	for name, got := range map[string]struct {
+		value *string
+		want  string
+	}{
+		"PublicLink":             {set.PublicLink, "https://fintual.cl/g/abc"},
+		"Regime":                 {set.Regime, "APV-A"},
+		"FundsSource":            {set.FundsSource, "salary"},
+		"FundsSourceDescription": {set.FundsSourceDescription, "Monthly salary"},
+	} {
+		if got.value == nil || *got.value != got.want {
+			t.Errorf("%s decoded as %v, want %q", name, got.value, got.want)
+		}
+	}
+	if set.GroupGoalID == nil || *set.GroupGoalID != 42 {
+		t.Errorf("GroupGoalID decoded as %v, want 42", set.GroupGoalID)
+	}
+}
@@ -0,0 +1,123 @@
+package fintual
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// RequestLog describes an attempt of a request made by the client.
+type RequestLog struct {
+	Operation Operation     // Service method which made the request
+	Method    string        // HTTP method of the request
+	URL       string        // URL of the request, with credentials redacted
+	Attempt   int           // Number of the attempt, starting at 1
+	Status    int           // Status code of the response, zero if none was received
+	Duration  time.Duration // Time until the response body was closed
+	Bytes     int64         // Number of bytes read from the response body
+	Err       error         // Error returned by the HTTP client, if any
+}
+
+// Logger receives a RequestLog for every attempt of every request made
+// by a client configured with WithLogger. Implementations must be safe
+// for concurrent use.
+type Logger interface {
+	LogRequest(ctx context.Context, r RequestLog)
+}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+// StdLogger returns a Logger which writes to l.
+func StdLogger(l *log.Logger) Logger {
+	return stdLogger{l: l}
+}
+
+func (s stdLogger) LogRequest(ctx context.Context, r RequestLog) {
+	if r.Err != nil {
+		s.l.Printf("fintual: %s %s %s attempt=%d duration=%v error=%v", r.Operation, r.Method, r.URL, r.Attempt, r.Duration, r.Err)
+		return
+	}
+	s.l.Printf("fintual: %s %s %s attempt=%d status=%d duration=%v bytes=%d", r.Operation, r.Method, r.URL, r.Attempt, r.Status, r.Duration, r.Bytes)
+}
+
+// LoggingMiddleware returns a Middleware which sends a RequestLog to l for
+// every attempt of every request. If a response is received, the attempt
+// is logged once its body is closed, with the number of bytes read from it.
+// Credentials are redacted from the logged URL and error.
+func LoggingMiddleware(l Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			op, _ := OperationFromContext(ctx)
+			start := time.Now()
+
+			resp, err := next.Do(req)
+			r := RequestLog{
+				Operation: op,
+				Method:    req.Method,
+				URL:       redactURL(req.URL),
+				Attempt:   attemptFromContext(ctx),
+				Err:       redactError(err),
+			}
+
+			if resp == nil {
+				r.Duration = time.Since(start)
+				l.LogRequest(ctx, r)
+				return resp, err
+			}
+
+			r.Status = resp.StatusCode
+			resp.Body = &loggedBody{ReadCloser: resp.Body, onClose: func(n int64) {
+				r.Duration = time.Since(start)
+				r.Bytes = n
+				l.LogRequest(ctx, r)
+			}}
+			return resp, err
+		})
+	}
+}
+
+type attemptKey struct{}
+
+// attemptFromContext returns the number of the attempt
+// of the request with context ctx, starting at 1.
+func attemptFromContext(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// loggedBody counts the bytes read from a response body
+// and calls onClose once the body is closed.
+type loggedBody struct {
+	io.ReadCloser
+	n       int64
+	once    sync.Once
+	onClose func(n int64)
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.onClose(b.n) })
+	return err
+}
+
+var secretRe = regexp.MustCompile(`("(?:password|token)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactBody replaces any password or access token in the JSON body b.
+func redactBody(b []byte) []byte {
+	return secretRe.ReplaceAll(b, []byte(`${1}"`+redacted+`"`))
+}
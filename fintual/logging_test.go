@@ -0,0 +1,68 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testLogger is a Logger recording the RequestLogs it receives.
+type testLogger struct {
+	mu   sync.Mutex
+	logs []RequestLog
+}
+
+func (l *testLogger) LogRequest(ctx context.Context, r RequestLog) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, r)
+}
+
+func TestWithLogger(t *testing.T) {
+	var requests int32
+	l := &testLogger{}
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}),
+		WithLogger(l),
+		WithAuthMethod(AuthQuery),
+		WithRetryPolicy(&RetryPolicy{MaxAttempts: 2, RetryableStatus: []int{http.StatusServiceUnavailable}}),
+	)
+	c.SetSession(Session{Email: "user@example.com", Token: "secret-token"})
+
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Fatalf("Goals.ListAll returned error: %v", err)
+	}
+
+	if len(l.logs) != 2 {
+		t.Fatalf("logged %d attempts, want 2", len(l.logs))
+	}
+	for i, r := range l.logs {
+		if r.Attempt != i+1 {
+			t.Errorf("log %d has attempt %d, want %d", i, r.Attempt, i+1)
+		}
+		if r.Operation.String() != "Goals.ListAll" || r.Method != "GET" {
+			t.Errorf("log %d is for %s %s, want Goals.ListAll GET", i, r.Operation, r.Method)
+		}
+		if strings.Contains(r.URL, "secret-token") {
+			t.Errorf("log %d URL %s contains the access token", i, r.URL)
+		}
+		if r.Duration <= 0 || r.Duration > time.Minute {
+			t.Errorf("log %d has duration %v", i, r.Duration)
+		}
+	}
+	if l.logs[0].Status != http.StatusServiceUnavailable || l.logs[1].Status != http.StatusOK {
+		t.Errorf("logged statuses %d and %d, want 503 and 200", l.logs[0].Status, l.logs[1].Status)
+	}
+	if l.logs[1].Bytes != int64(len(`{"data":[]}`)) {
+		t.Errorf("logged %d bytes, want %d", l.logs[1].Bytes, len(`{"data":[]}`))
+	}
+}
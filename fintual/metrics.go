@@ -0,0 +1,36 @@
+package fintual
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestMetrics describes an attempt of a request made by the client.
+type RequestMetrics struct {
+	Endpoint string        // Templated path of the endpoint, e.g. "/real_assets/:id/days"
+	Method   string        // HTTP method of the request
+	Status   int           // Status code of the response, zero if none was received
+	Duration time.Duration // Time until the response headers were received
+	Err      error         // Error returned by the HTTP client, if any
+}
+
+// MetricsCollector receives metrics about the requests made by a client.
+// Endpoints are templated paths, such as "/real_assets/:id/days", which
+// keeps the cardinality of labels low. Implementations must be safe for
+// concurrent use.
+type MetricsCollector interface {
+	// ObserveRequest is called after every attempt of a request.
+	ObserveRequest(m RequestMetrics)
+	// IncRetries is called every time a request is retried.
+	IncRetries(endpoint, method string)
+	// IncCacheHits is called every time a response is served from a cache.
+	IncCacheHits(endpoint string)
+}
+
+// endpointOf returns the templated path of the endpoint requested by req.
+func endpointOf(req *http.Request) string {
+	if op, ok := OperationFromContext(req.Context()); ok && op.Endpoint != "" {
+		return op.Endpoint
+	}
+	return "unknown"
+}
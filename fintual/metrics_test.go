@@ -0,0 +1,80 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testMetrics is a MetricsCollector recording the metrics it receives.
+type testMetrics struct {
+	mu        sync.Mutex
+	requests  []RequestMetrics
+	retries   []string
+	cacheHits []string
+}
+
+func (m *testMetrics) ObserveRequest(r RequestMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, r)
+}
+
+func (m *testMetrics) IncRetries(endpoint, method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries = append(m.retries, method+" "+endpoint)
+}
+
+func (m *testMetrics) IncCacheHits(endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheHits = append(m.cacheHits, endpoint)
+}
+
+func TestWithMetrics_labelsTemplatedEndpoints(t *testing.T) {
+	const endpoint = "/real_assets/:id/days"
+
+	var requests int32
+	days := &daysHandler{}
+	m := &testMetrics{}
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		days.ServeHTTP(w, r)
+	}),
+		WithMetrics(m),
+		WithDayCache(NewDayCache()),
+		WithRetryPolicy(&RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, RetryableStatus: []int{http.StatusServiceUnavailable}}),
+	)
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-10")
+	for i := 0; i < 2; i++ {
+		if _, err := c.RealAssets.ListDaysByDates(context.Background(), "186", from, to); err != nil {
+			t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+		}
+	}
+
+	if len(m.requests) != 2 {
+		t.Fatalf("observed %d requests, want 2", len(m.requests))
+	}
+	for i, r := range m.requests {
+		if r.Endpoint != endpoint || r.Method != "GET" {
+			t.Errorf("request %d observed as %s %s, want GET %s", i, r.Method, r.Endpoint, endpoint)
+		}
+	}
+	if m.requests[0].Status != http.StatusServiceUnavailable || m.requests[1].Status != http.StatusOK {
+		t.Errorf("observed statuses %d and %d, want 503 and 200", m.requests[0].Status, m.requests[1].Status)
+	}
+	if len(m.retries) != 1 || m.retries[0] != "GET "+endpoint {
+		t.Errorf("retries = %q, want [GET %s]", m.retries, endpoint)
+	}
+	if len(m.cacheHits) != 1 || m.cacheHits[0] != endpoint {
+		t.Errorf("cache hits = %q, want [%s]", m.cacheHits, endpoint)
+	}
+}
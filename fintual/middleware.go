@@ -0,0 +1,143 @@
+package fintual
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+)
+
+const defaultRequestIDHeader = "X-Request-Id"
+
+// Doer sends HTTP requests and returns HTTP responses.
+// *http.Client implements Doer.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc is an adapter to allow the use of ordinary functions as a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer to inspect or modify requests and responses.
+// Every attempt of a request made by the client goes through its
+// middlewares. The operation which made the request can be retrieved
+// from its context with OperationFromContext.
+type Middleware func(next Doer) Doer
+
+// chain wraps d with the given middlewares. The first
+// middleware is the outermost one.
+func chain(d Doer, mws []Middleware) Doer {
+	for i := len(mws) - 1; i >= 0; i-- {
+		d = mws[i](d)
+	}
+	return d
+}
+
+// RequestIDMiddleware returns a Middleware which adds a random request ID
+// to every request in the given header, unless the request already has one.
+// If header is empty, X-Request-Id is used.
+func RequestIDMiddleware(header string) Middleware {
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(header) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(header, newRequestID())
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// newRequestID returns a random 16 bytes hex encoded ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// HeaderMiddleware returns a Middleware which sets the given
+// headers on every request, replacing any existing values.
+func HeaderMiddleware(h http.Header) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			for k, v := range h {
+				req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// DumpMiddleware returns a Middleware which writes every request and
+// response, including their bodies, to w. It is meant for debugging.
+// Credentials are redacted from the dumped headers, URLs and bodies.
+func DumpMiddleware(w io.Writer) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if b, err := dumpRequest(req); err == nil {
+				fmt.Fprintf(w, "%s\n", b)
+			}
+
+			resp, err := next.Do(req)
+			if err != nil {
+				fmt.Fprintf(w, "fintual: %s %s failed: %v\n\n", req.Method, redactURL(req.URL), redactError(err))
+				return resp, err
+			}
+
+			if b, err := httputil.DumpResponse(resp, true); err == nil {
+				fmt.Fprintf(w, "%s\n\n", redactBody(b))
+			}
+			return resp, nil
+		})
+	}
+}
+
+// dumpRequest dumps a redacted copy of req, without consuming its body.
+func dumpRequest(req *http.Request) ([]byte, error) {
+	r := req.Clone(req.Context())
+	r.Body = nil
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+		b = redactBody(b)
+		r.Body = ioutil.NopCloser(bytes.NewReader(b))
+		r.ContentLength = int64(len(b))
+	}
+
+	u, err := url.Parse(redactURL(req.URL))
+	if err != nil {
+		return nil, err
+	}
+	r.URL = u
+	for _, k := range []string{userEmailHeader, userTokenHeader} {
+		if r.Header.Get(k) != "" {
+			r.Header.Set(k, redacted)
+		}
+	}
+
+	return httputil.DumpRequestOut(r, r.Body != nil)
+}
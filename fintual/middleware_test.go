@@ -0,0 +1,93 @@
+package fintual
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// headerRecorder serves an empty list, recording the
+// headers of the last request it received.
+type headerRecorder struct {
+	header http.Header
+}
+
+func (h *headerRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.header = r.Header.Clone()
+	w.Write([]byte(`{"data":[]}`))
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	h := &headerRecorder{}
+	c := setup(t, h, WithMiddleware(RequestIDMiddleware("")))
+
+	if _, err := c.Banks.ListAll(context.Background(), nil); err != nil {
+		t.Fatalf("Banks.ListAll returned error: %v", err)
+	}
+	if id := h.header.Get(defaultRequestIDHeader); !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Errorf("%s header = %q, want 32 hex digits", defaultRequestIDHeader, id)
+	}
+}
+
+func TestRequestIDMiddleware_keepsExistingID(t *testing.T) {
+	h := &headerRecorder{}
+	c := setup(t, h, WithHeader("X-Trace", "abc"), WithMiddleware(RequestIDMiddleware("X-Trace")))
+
+	if _, err := c.Banks.ListAll(context.Background(), nil); err != nil {
+		t.Fatalf("Banks.ListAll returned error: %v", err)
+	}
+	if got := h.header.Values("X-Trace"); len(got) != 1 || got[0] != "abc" {
+		t.Errorf("X-Trace header = %q, want [abc]", got)
+	}
+	if id := h.header.Get(defaultRequestIDHeader); id != "" {
+		t.Errorf("%s header = %q, want none", defaultRequestIDHeader, id)
+	}
+}
+
+func TestHeaderMiddleware_replacesHeaders(t *testing.T) {
+	h := &headerRecorder{}
+	c := setup(t, h,
+		WithHeader("X-Team", "pricing"),
+		WithMiddleware(HeaderMiddleware(http.Header{
+			"x-team":     {"risk", "ops"},
+			"User-Agent": {"custom/1.0"},
+		})),
+	)
+
+	if _, err := c.Banks.ListAll(context.Background(), nil); err != nil {
+		t.Fatalf("Banks.ListAll returned error: %v", err)
+	}
+	if got := h.header.Values("X-Team"); len(got) != 2 || got[0] != "risk" || got[1] != "ops" {
+		t.Errorf("X-Team header = %q, want [risk ops]", got)
+	}
+	if got := h.header.Get("User-Agent"); got != "custom/1.0" {
+		t.Errorf("User-Agent header = %q, want %q", got, "custom/1.0")
+	}
+}
+
+func TestDumpMiddleware_redactsCredentials(t *testing.T) {
+	var buf bytes.Buffer
+	c := setup(t, &authHandler{token: testToken}, WithMiddleware(DumpMiddleware(&buf)))
+
+	if err := c.Authenticate(context.Background(), testEmail, "hunter2-password"); err != nil {
+		t.Fatalf("Authenticate returned error: %v", err)
+	}
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Fatalf("Goals.ListAll returned error: %v", err)
+	}
+
+	dump := buf.String()
+	for _, want := range []string{"POST " + accessTokenEndpoint, `"password":"` + redacted + `"`, `"token":"` + redacted + `"`, "GET /goals"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump does not contain %q:\n%s", want, dump)
+		}
+	}
+	for _, secret := range []string{"hunter2-password", testToken} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("dump contains %q:\n%s", secret, dump)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package fintual
+
+import (
+	"context"
+	"sync"
+)
+
+// Operation identifies the service method which made a request.
+type Operation struct {
+	Service  string // Name of the service, e.g. "RealAssets"
+	Method   string // Name of the method, e.g. "ListDaysByDates"
+	Endpoint string // Templated path of the endpoint, e.g. "/real_assets/:id/days"
+}
+
+func (o Operation) String() string {
+	return o.Service + "." + o.Method
+}
+
+type operationKey struct{}
+
+// operation is an in-flight call to a service method, which may
+// make one or more requests.
+type operation struct {
+	Operation
+	span Span // Span of the operation, nil if the client has no tracer
+
+	mu       sync.Mutex
+	attempts int   // Attempts made by the requests of the operation
+	status   int   // Status code of the last response
+	err      error // First error returned by a request
+	holds    int   // Shared requests still running with the span of the operation
+	ending   bool  // Whether end was called while holds was not zero
+}
+
+// startOperation returns a copy of ctx carrying a new operation, which
+// must be ended by calling its end method. If the client has a tracer,
+// a span is started for the operation with the given attributes.
+func (c *Client) startOperation(ctx context.Context, service, method, endpoint string, attrs ...Attribute) (context.Context, *operation) {
+	op := &operation{Operation: Operation{Service: service, Method: method, Endpoint: endpoint}}
+	if c.tracer != nil {
+		ctx, op.span = c.tracer.Start(ctx, op.String(), attrs...)
+	}
+	return context.WithValue(ctx, operationKey{}, op), op
+}
+
+// operationFromContext returns the operation carried by ctx, if any.
+func operationFromContext(ctx context.Context) *operation {
+	op, _ := ctx.Value(operationKey{}).(*operation)
+	return op
+}
+
+// OperationFromContext returns the operation which made a request, given
+// the request's context. It is meant to be used by middlewares.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op := operationFromContext(ctx)
+	if op == nil {
+		return Operation{}, false
+	}
+	return op.Operation, true
+}
+
+// record records the outcome of a request made by the operation.
+func (op *operation) record(resp *Response, attempts int, err error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	op.attempts += attempts
+	if resp != nil {
+		op.status = resp.StatusCode
+	}
+	if op.err == nil {
+		op.err = err
+	}
+}
+
+// hold keeps the span of the operation from ending until release is
+// called, while a request shared with other operations runs with it.
+func (op *operation) hold() {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.holds++
+}
+
+// release undoes a call to hold, ending the span of
+// the operation if end was called in the meantime.
+func (op *operation) release() {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	op.holds--
+	if op.holds == 0 && op.ending {
+		op.finish()
+	}
+}
+
+// end ends the span of the operation, or once every shared
+// request running with it is done.
+func (op *operation) end() {
+	if op.span == nil {
+		return
+	}
+
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	if op.holds > 0 {
+		op.ending = true
+		return
+	}
+	op.finish()
+}
+
+// finish sets the outcome of the operation on its span and ends it.
+// op.mu must be held.
+func (op *operation) finish() {
+	if op.status != 0 {
+		op.span.SetAttributes(Attribute{Key: attrStatusCode, Value: op.status})
+	}
+	if op.attempts > 1 {
+		op.span.SetAttributes(Attribute{Key: attrResendCount, Value: op.attempts - 1})
+	}
+	op.span.End(op.err)
+}
@@ -0,0 +1,229 @@
+package fintual
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created with New.
+type Option func(*Client) error
+
+// WithHTTPClient sets the HTTP client used to communicate with the API.
+// If a nil httpClient is provided, a new http.Client will be used.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient != nil {
+			c.http = httpClient
+		}
+		return nil
+	}
+}
+
+// WithBaseURL sets the base URL for API requests,
+// e.g. a staging server or a local stand-in.
+func WithBaseURL(rawURL string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(strings.TrimSuffix(rawURL, "/"))
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return errors.New("fintual: base URL must be absolute")
+		}
+		c.baseURL = u
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) error {
+		c.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithHeader adds a header sent with every request. Its values replace
+// those set by the client, such as User-Agent or Accept.
+func WithHeader(key, value string) Option {
+	return func(c *Client) error {
+		c.headers.Add(key, value)
+		return nil
+	}
+}
+
+// WithTimeout sets the time limit for requests made by the HTTP client.
+// It does not modify an http.Client given to WithHTTPClient, which is
+// copied instead.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) error {
+		c.timeout = d
+		return nil
+	}
+}
+
+// WithRetryPolicy sets the retry policy used by the client.
+// A nil policy disables retries, which is the default.
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(c *Client) error {
+		c.retry = p
+		return nil
+	}
+}
+
+// WithRateLimiter sets the rate limiter used by the client.
+// A nil limiter disables rate limiting, which is the default.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(c *Client) error {
+		c.limiter = l
+		return nil
+	}
+}
+
+// WithAuthMethod sets how authentication credentials are sent to the API.
+// Credentials are sent in headers by default.
+func WithAuthMethod(m AuthMethod) Option {
+	return func(c *Client) error {
+		c.authMethod = m
+		return nil
+	}
+}
+
+// WithTokenStore sets the store used for persisting sessions, which
+// Client.Authenticate consults before requesting a new access token.
+func WithTokenStore(store TokenStore) Option {
+	return func(c *Client) error {
+		c.tokens = store
+		return nil
+	}
+}
+
+// WithCredentialsProvider sets the provider of the credentials used to
+// authenticate the client when a request requiring authentication is made
+// without a session, or when the API rejects the current access token.
+func WithCredentialsProvider(p CredentialsProvider) Option {
+	return func(c *Client) error {
+		c.credentials = p
+		return nil
+	}
+}
+
+// WithMiddleware adds middlewares wrapping every request made by the
+// client. Middlewares are applied in the given order, the first one
+// being the outermost.
+func WithMiddleware(mws ...Middleware) Option {
+	return func(c *Client) error {
+		c.middlewares = append(c.middlewares, mws...)
+		return nil
+	}
+}
+
+// WithLogger enables debug mode, in which every attempt of every request
+// made by the client is logged to l, by a LoggingMiddleware wrapped by any
+// other middleware. Credentials are redacted from the logged URLs.
+func WithLogger(l Logger) Option {
+	return func(c *Client) error {
+		c.logger = l
+		return nil
+	}
+}
+
+// WithTracer sets the tracer used to start spans around
+// every operation and HTTP attempt made by the client.
+func WithTracer(t Tracer) Option {
+	return func(c *Client) error {
+		c.tracer = t
+		return nil
+	}
+}
+
+// WithMetrics sets the collector of metrics about the requests
+// made by the client.
+func WithMetrics(m MetricsCollector) Option {
+	return func(c *Client) error {
+		c.metrics = m
+		return nil
+	}
+}
+
+// WithCache enables caching the responses of the catalog endpoints, used by
+// Banks.ListAll, AssetProviders.ListAll and ConceptualAssets.ListAll, in the
+// given cache. Responses are served from the cache for ttl, after which they
+// are revalidated with a conditional request (If-None-Match and
+// If-Modified-Since). A zero ttl revalidates responses on every request.
+// Other endpoints which do not require authentication may be cached with
+// WithCacheTTL.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(c *Client) error {
+		if c.cache == nil {
+			c.cache = &responseCache{ttls: make(map[string]time.Duration)}
+		}
+		c.cache.store = cache
+		c.cache.ttl = ttl
+		return nil
+	}
+}
+
+// WithCacheTTL enables caching the responses of an endpoint which does
+// not require authentication, given by its templated path, e.g.
+// "/real_assets/:id", or overrides their time to live. A negative ttl disables
+// caching for the endpoint. It has no effect without WithCache.
+func WithCacheTTL(endpoint string, ttl time.Duration) Option {
+	return func(c *Client) error {
+		if c.cache == nil {
+			c.cache = &responseCache{ttls: make(map[string]time.Duration)}
+		}
+		c.cache.ttls[endpoint] = ttl
+		return nil
+	}
+}
+
+// WithDayCache sets the cache of real asset days used by
+// RealAssetsService.ListDaysByDates.
+func WithDayCache(dc *DayCache) Option {
+	return func(c *Client) error {
+		c.days = dc
+		return nil
+	}
+}
+
+// WithCircuitBreaker sets the circuit breaker used by the client. While
+// the circuit is open, requests fail immediately with ErrCircuitOpen.
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(c *Client) error {
+		c.breaker = b
+		return nil
+	}
+}
+
+// WithBatchConcurrency sets the maximum number of concurrent requests made
+// by batch methods, such as RealAssetsService.GetMany, and by the windows
+// of long ranges given to RealAssetsService.ListDaysByDates. The limit is
+// shared by all of them. Defaults to 4.
+func WithBatchConcurrency(n int) Option {
+	return func(c *Client) error {
+		if n < 1 {
+			return errors.New("fintual: batch concurrency must be positive")
+		}
+		c.batchLimit = n
+		return nil
+	}
+}
+
+// WithDaysWindow sets the maximum number of days requested at once by
+// RealAssetsService.ListDaysByDates. Longer ranges are split into windows
+// of n days, which are requested concurrently within the batch concurrency
+// limit, see WithBatchConcurrency. A zero n disables splitting.
+// Defaults to 365 days.
+func WithDaysWindow(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return errors.New("fintual: days window must not be negative")
+		}
+		c.daysWindow = n
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithBaseURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://staging.example.com/api/", "https://staging.example.com/api", false},
+		{"http://localhost:8080", "http://localhost:8080", false},
+		{"/api", "", true},
+		{"staging.example.com/api", "", true},
+		{"://bad", "", true},
+	}
+
+	for _, tt := range tests {
+		c, err := New(WithBaseURL(tt.url))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(WithBaseURL(%q)) returned error %v, want error %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if err == nil && c.baseURL.String() != tt.want {
+			t.Errorf("New(WithBaseURL(%q)) has base URL %s, want %s", tt.url, c.baseURL, tt.want)
+		}
+	}
+}
+
+func TestWithTimeout_copiesHTTPClient(t *testing.T) {
+	hc := &http.Client{Timeout: time.Minute}
+	c, err := New(WithHTTPClient(hc), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	if hc.Timeout != time.Minute {
+		t.Errorf("given http.Client has timeout %v, want it unchanged", hc.Timeout)
+	}
+	if c.http == hc || c.http.Timeout != 5*time.Second {
+		t.Errorf("client uses http.Client %p with timeout %v, want a copy of %p with timeout 5s", c.http, c.http.Timeout, hc)
+	}
+}
+
+func TestNewRequest_headers(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		wantUserAgent string
+		wantAccept    string
+	}{
+		{"default", nil, "go-fintual/" + Version, "application/json"},
+		{"WithUserAgent", []Option{WithUserAgent("my-app/1.0")}, "my-app/1.0", "application/json"},
+		{"WithHeader", []Option{
+			WithUserAgent("my-app/1.0"),
+			WithHeader("User-Agent", "other/2.0"),
+			WithHeader("Accept", "application/vnd.api+json"),
+		}, "other/2.0", "application/vnd.api+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &headerRecorder{}
+			c := setup(t, h, tt.opts...)
+
+			if _, err := c.Banks.ListAll(context.Background(), nil); err != nil {
+				t.Fatalf("Banks.ListAll returned error: %v", err)
+			}
+			if got := h.header.Values("User-Agent"); len(got) != 1 || got[0] != tt.wantUserAgent {
+				t.Errorf("User-Agent header = %q, want [%s]", got, tt.wantUserAgent)
+			}
+			if got := h.header.Values("Accept"); len(got) != 1 || got[0] != tt.wantAccept {
+				t.Errorf("Accept header = %q, want [%s]", got, tt.wantAccept)
+			}
+		})
+	}
+}
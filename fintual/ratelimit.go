@@ -0,0 +1,144 @@
+package fintual
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultThrottle is how long a RateLimiter pauses after a 429 response
+// without a Retry-After header.
+const defaultThrottle = time.Second
+
+// RateLimiter is a token bucket rate limiter which every request made by
+// a Client waits on. It is safe for concurrent use, so a single RateLimiter
+// may be shared between several clients.
+//
+// The limiter adapts to 429 (Too Many Requests) responses: it stops handing
+// out tokens until the time given by the Retry-After header and halves its
+// rate, which then recovers gradually with every successful response.
+type RateLimiter struct {
+	mu          sync.Mutex
+	limit       float64   // Configured tokens per second
+	rate        float64   // Current tokens per second
+	burst       float64   // Maximum number of tokens in the bucket
+	tokens      float64   // Available tokens, negative when reserved ahead
+	last        time.Time // Last time tokens were refilled
+	pausedUntil time.Time // No tokens are handed out before this time
+}
+
+// NewRateLimiter returns a RateLimiter which allows up to rps requests
+// per second, with bursts of up to burst requests. A rps lower or equal
+// to zero only limits requests after a 429 response.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		limit:  rps,
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request is allowed to proceed or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	d := l.reserve()
+	if err := sleep(ctx, d); err != nil {
+		l.cancel()
+		return err
+	}
+	return nil
+}
+
+// reserve takes a token from the bucket and returns how long
+// the caller must wait before using it.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.refill(now)
+	l.tokens--
+
+	var d time.Duration
+	if pause := l.pausedUntil.Sub(now); pause > 0 {
+		d = pause
+	}
+	if l.tokens < 0 && l.rate > 0 {
+		// Tokens owed are only refilled once the pause is over.
+		d += time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	return d
+}
+
+// cancel gives back a token taken by reserve.
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = math.Min(l.tokens+1, l.burst)
+}
+
+// refill adds the tokens accumulated since the last refill.
+// No tokens accumulate while the limiter is paused.
+// l.mu must be held.
+func (l *RateLimiter) refill(now time.Time) {
+	if !now.After(l.last) {
+		return
+	}
+	from := l.last
+	if l.pausedUntil.After(from) {
+		from = l.pausedUntil
+	}
+	if elapsed := now.Sub(from).Seconds(); elapsed > 0 {
+		l.tokens = math.Min(l.tokens+elapsed*l.rate, l.burst)
+	}
+	l.last = now
+}
+
+// observe adapts the limiter to the status of a response.
+func (l *RateLimiter) observe(resp *http.Response) {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		d, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			d = defaultThrottle
+		}
+		l.throttle(d)
+		return
+	}
+	if resp.StatusCode < 500 {
+		l.relax()
+	}
+}
+
+// throttle pauses the limiter for d, empties its bucket and halves its
+// rate, so that requests resume at the lower rate instead of in a burst.
+func (l *RateLimiter) throttle(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.refill(now)
+	l.tokens = math.Min(l.tokens, 0)
+	if until := now.Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.rate = math.Max(l.rate/2, l.limit/16)
+}
+
+// relax moves the rate of the limiter back towards its configured limit.
+func (l *RateLimiter) relax() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate >= l.limit {
+		return
+	}
+	l.refill(time.Now())
+	l.rate = math.Min(l.rate+l.limit/16, l.limit)
+}
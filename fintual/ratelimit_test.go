@@ -0,0 +1,97 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_throttleSpreadsRequestsAfterPause(t *testing.T) {
+	l := NewRateLimiter(5, 10)
+	l.throttle(2 * time.Second)
+
+	prev := time.Duration(0)
+	for i := 0; i < 30; i++ {
+		d := l.reserve()
+		if d < 2*time.Second-50*time.Millisecond {
+			t.Fatalf("reserve %d waits %v, want at least the 2s pause", i, d)
+		}
+		// The rate is halved to 2.5 requests per second.
+		if i > 0 && d-prev < 350*time.Millisecond {
+			t.Fatalf("reserve %d waits %v, only %v after the previous one", i, d, d-prev)
+		}
+		prev = d
+	}
+}
+
+func TestRateLimiter_observe(t *testing.T) {
+	l := NewRateLimiter(16, 1)
+
+	l.observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"1"}}})
+	if l.rate != 8 {
+		t.Errorf("rate after 429 = %v, want 8", l.rate)
+	}
+	if pause := time.Until(l.pausedUntil); pause < 900*time.Millisecond || pause > time.Second {
+		t.Errorf("pause after Retry-After: 1 = %v, want about 1s", pause)
+	}
+
+	l.observe(&http.Response{StatusCode: http.StatusServiceUnavailable})
+	if l.rate != 8 {
+		t.Errorf("rate after 503 = %v, want 8", l.rate)
+	}
+
+	for i := 0; i < 20; i++ {
+		l.observe(&http.Response{StatusCode: http.StatusOK})
+	}
+	if l.rate != 16 {
+		t.Errorf("rate after 200s = %v, want the limit of 16", l.rate)
+	}
+}
+
+func TestRateLimiter_throttleFloor(t *testing.T) {
+	l := NewRateLimiter(16, 1)
+	for i := 0; i < 10; i++ {
+		l.throttle(0)
+	}
+	if l.rate != 1 {
+		t.Errorf("rate after 10 throttles = %v, want 1", l.rate)
+	}
+}
+
+func TestRateLimiter_withoutRate(t *testing.T) {
+	l := NewRateLimiter(0, 1)
+
+	for i := 0; i < 100; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("reserve %d waits %v, want no wait", i, d)
+		}
+	}
+
+	l.throttle(time.Second)
+	for i := 0; i < 10; i++ {
+		if d := l.reserve(); d < 900*time.Millisecond || d > time.Second {
+			t.Fatalf("reserve %d waits %v during pause, want about 1s", i, d)
+		}
+	}
+}
+
+func TestRateLimiter_giveBackTokenOnCancel(t *testing.T) {
+	l := NewRateLimiter(1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait with canceled context returned %v, want context.Canceled", err)
+	}
+
+	// Without the canceled reservation, the next request
+	// only waits for one token.
+	if d := l.reserve(); d > time.Second {
+		t.Errorf("reserve after cancel waits %v, want at most 1s", d)
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"sort"
+	"sync"
+	"time"
 )
 
 const (
 	realAssetsEndpoint   = "/real_assets"
 	expenseRatioEndpoint = "/expense_ratio"
 	daysEndpoint         = "/days"
+
+	defaultDaysWindow = 365
 )
 
 // RealAssetsService handles communication with the
@@ -26,14 +30,14 @@ type RealAsset struct {
 }
 
 type RealAssetAttributes struct {
-	Name              string      `json:"name"`
-	Symbol            string      `json:"symbol"`
-	Serie             string      `json:"serie"`
-	StartDate         string      `json:"start_date"`
-	EndDate           interface{} `json:"end_date"`
-	PreviousAssetID   interface{} `json:"previous_asset_id"`
-	LastDay           LastDay     `json:"last_day"`
-	ConceptualAssetID int         `json:"conceptual_asset_id"`
+	Name              string  `json:"name"`
+	Symbol            string  `json:"symbol"`
+	Serie             string  `json:"serie"`
+	StartDate         Date    `json:"start_date"`
+	EndDate           *Date   `json:"end_date"`          // Nil while the asset is active
+	PreviousAssetID   *int    `json:"previous_asset_id"` // Nil if the asset replaced no other
+	LastDay           LastDay `json:"last_day"`
+	ConceptualAssetID int     `json:"conceptual_asset_id"`
 }
 
 type LastDay struct {
@@ -52,25 +56,35 @@ type LastDay struct {
 	RedeemedShares         float64 `json:"redeemed_shares"`
 	InstitutionalInvestors float64 `json:"institutional_investors"`
 	Shareholders           float64 `json:"shareholders"`
-	Date                   string  `json:"date"`
+	Date                   Date    `json:"date"`
 }
 
 // Get retrieves a single Real Asset.
 //
 // Endpoint: GET /real_assets/:id
 func (s *RealAssetsService) Get(ctx context.Context, id string) (*RealAsset, error) {
+	ra, _, err := s.GetWithResponse(ctx, id)
+	return ra, err
+}
+
+// GetWithResponse is like Get, but it also returns
+// the response of the API.
+func (s *RealAssetsService) GetWithResponse(ctx context.Context, id string) (*RealAsset, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "RealAssets", "Get", "/real_assets/:id", attr(attrResourceID, id))
+	defer op.end()
+
 	url := fmt.Sprintf("%s/%s", s.client.baseURL.String()+realAssetsEndpoint, id)
 
 	var ra struct {
 		Data *RealAsset `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &ra)
+	resp, err := s.client.get(ctx, url, &ra)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return ra.Data, nil
+	return ra.Data, resp, nil
 }
 
 type ExpenseRationRealAsset struct {
@@ -87,18 +101,28 @@ type ExpenseRationRealAssetAttributes struct {
 //
 // Endpoint: GET /real_assets/:id/expense_ratio
 func (s *RealAssetsService) GetExpenseRatio(ctx context.Context, id string) (*ExpenseRationRealAsset, error) {
+	ra, _, err := s.GetExpenseRatioWithResponse(ctx, id)
+	return ra, err
+}
+
+// GetExpenseRatioWithResponse is like GetExpenseRatio, but it also returns
+// the response of the API.
+func (s *RealAssetsService) GetExpenseRatioWithResponse(ctx context.Context, id string) (*ExpenseRationRealAsset, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "RealAssets", "GetExpenseRatio", "/real_assets/:id/expense_ratio", attr(attrResourceID, id))
+	defer op.end()
+
 	url := fmt.Sprintf("%s/%s%s", s.client.baseURL.String()+realAssetsEndpoint, id, expenseRatioEndpoint)
 
 	var ra struct {
 		Data *ExpenseRationRealAsset `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &ra)
+	resp, err := s.client.get(ctx, url, &ra)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return ra.Data, nil
+	return ra.Data, resp, nil
 }
 
 type RealAssetDay struct {
@@ -108,7 +132,7 @@ type RealAssetDay struct {
 }
 
 type RealAssetDayAttributes struct {
-	Date                       string  `json:"date"`
+	Date                       Date    `json:"date"`
 	Price                      float64 `json:"price"`
 	FixedManagementFee         float64 `json:"fixed_management_fee"`
 	FixedManagementFeeType     string  `json:"fixed_management_fee_type"`
@@ -143,12 +167,22 @@ type RealAssetDayAttributes struct {
 }
 
 // GetDay retrieves a Real Asset Day. Receives a Real Asset ID
-// and a string date with format YYYY-MM-DD.
+// and a date.
 //
 // Endpoint: GET /real_assets/:id/days
-func (s *RealAssetsService) GetDay(ctx context.Context, id string, date string) ([]*RealAssetDay, error) {
-	if date == "" || len(strings.Split(date, "-")) != 3 {
-		return nil, errors.New("received malformatted or zero value date")
+func (s *RealAssetsService) GetDay(ctx context.Context, id string, date Date) ([]*RealAssetDay, error) {
+	rad, _, err := s.GetDayWithResponse(ctx, id, date)
+	return rad, err
+}
+
+// GetDayWithResponse is like GetDay, but it also returns
+// the response of the API.
+func (s *RealAssetsService) GetDayWithResponse(ctx context.Context, id string, date Date) ([]*RealAssetDay, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "RealAssets", "GetDay", "/real_assets/:id/days", attr(attrResourceID, id), attr(attrDate, date.String()))
+	defer op.end()
+
+	if !date.IsValid() {
+		return nil, nil, errors.New("received malformatted or zero value date")
 	}
 
 	url := fmt.Sprintf("%s/%s%s?date=%s", s.client.baseURL.String()+realAssetsEndpoint, id, daysEndpoint, date)
@@ -157,35 +191,197 @@ func (s *RealAssetsService) GetDay(ctx context.Context, id string, date string)
 		Data []*RealAssetDay `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &rad)
+	resp, err := s.client.get(ctx, url, &rad)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return rad.Data, nil
+	return rad.Data, resp, nil
 }
 
 // ListDaysByDates lists Real Asset Days. Receives a Real Asset ID
-// and a from and to string dates with format YYYY-MM-DD.
+// and the first and last dates of the days to list.
+//
+// Long ranges are split into windows, see WithDaysWindow, which are
+// requested concurrently. Days are returned sorted by date.
 //
 // Endpoint: GET /real_assets/:id/days
-func (s *RealAssetsService) ListDaysByDates(ctx context.Context, id, from, to string) ([]*RealAssetDay, error) {
-	if from == "" || to == "" || len(strings.Split(from, "-")) != 3 || len(strings.Split(to, "-")) != 3 {
-		return nil, errors.New("received malformatted or zero value dates")
+func (s *RealAssetsService) ListDaysByDates(ctx context.Context, id string, from, to Date) ([]*RealAssetDay, error) {
+	rad, _, err := s.ListDaysByDatesWithResponse(ctx, id, from, to)
+	return rad, err
+}
+
+// ListDaysByDatesWithResponse is like ListDaysByDates, but it also returns
+// the response of the API.
+//
+// When the days are fetched with several requests, e.g. one per window,
+// the returned Response wraps the HTTP response to the last of them in date
+// order, while its Attempts and Duration cover every request of the call.
+// Cached is only set if every day was served from a cache.
+func (s *RealAssetsService) ListDaysByDatesWithResponse(ctx context.Context, id string, from, to Date) ([]*RealAssetDay, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "RealAssets", "ListDaysByDates", "/real_assets/:id/days", attr(attrResourceID, id), attr(attrFromDate, from.String()), attr(attrToDate, to.String()))
+	defer op.end()
+
+	if !from.IsValid() || !to.IsValid() {
+		return nil, nil, errors.New("received malformatted or zero value dates")
+	}
+
+	if s.client.days != nil {
+		return s.listDaysCached(ctx, id, from, to)
 	}
 
+	return s.fetchDays(ctx, id, from, to)
+}
+
+// fetchDays requests the days of a real asset within the given dates.
+// Ranges longer than the client's days window are split into windows,
+// which are requested concurrently, each holding a batch slot, and then
+// merged. Within a batch call, which already holds a slot, windows are
+// requested one at a time.
+func (s *RealAssetsService) fetchDays(ctx context.Context, id string, from, to Date) ([]*RealAssetDay, *Response, error) {
+	start := time.Now()
+	windows := splitRange(dateRange{from, to}, s.client.daysWindow)
+	if len(windows) == 1 {
+		return s.fetchDayRange(ctx, id, from, to)
+	}
+
+	if holdsSlot(ctx) {
+		var all [][]*RealAssetDay
+		var resps []*Response
+		for _, w := range windows {
+			d, r, err := s.fetchDayRange(ctx, id, w.from, w.to)
+			if err != nil {
+				return nil, r, err
+			}
+			all, resps = append(all, d), append(resps, r)
+		}
+		return mergeDays(all), combineResponses(start, resps), nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		days  = make([][]*RealAssetDay, len(windows))
+		resps = make([]*Response, len(windows))
+		wg    sync.WaitGroup
+
+		mu       sync.Mutex
+		firstErr error
+		errResp  *Response
+	)
+	fail := func(resp *Response, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr, errResp = err, resp
+			cancel()
+		}
+	}
+
+	for i, w := range windows {
+		wg.Add(1)
+		go func(i int, w dateRange) {
+			defer wg.Done()
+
+			ctx, err := s.client.acquireSlot(ctx)
+			if err != nil {
+				fail(nil, err)
+				return
+			}
+			defer s.client.releaseSlot()
+
+			d, resp, err := s.fetchDayRange(ctx, id, w.from, w.to)
+			if err != nil {
+				fail(resp, err)
+				return
+			}
+			days[i], resps[i] = d, resp
+		}(i, w)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, errResp, firstErr
+	}
+	return mergeDays(days), combineResponses(start, resps), nil
+}
+
+// combineResponses returns the Response of a call started at start, which
+// made a request for each of resps, in order. It wraps the HTTP response
+// of the last request and counts the attempts made by every request.
+func combineResponses(start time.Time, resps []*Response) *Response {
+	var r Response
+	r.Cached = true
+	for _, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		r.Response = resp.Response
+		r.Attempts += resp.Attempts
+		r.Cached = r.Cached && resp.Cached
+	}
+	r.Duration = time.Since(start)
+	return &r
+}
+
+// fetchDayRange requests the days of a real asset within
+// the given dates with a single request.
+func (s *RealAssetsService) fetchDayRange(ctx context.Context, id string, from, to Date) ([]*RealAssetDay, *Response, error) {
 	url := fmt.Sprintf("%s/%s%s?from_date=%s&to_date=%s", s.client.baseURL.String()+realAssetsEndpoint, id, daysEndpoint, from, to)
 
 	var rad struct {
 		Data []*RealAssetDay `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &rad)
+	resp, err := s.client.get(ctx, url, &rad)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
+	}
+
+	return rad.Data, resp, nil
+}
+
+// splitRange splits r into consecutive windows of up to n days.
+// If n is not positive, r is not split.
+func splitRange(r dateRange, n int) []dateRange {
+	if n <= 0 {
+		return []dateRange{r}
 	}
 
-	return rad.Data, nil
+	var windows []dateRange
+	for from := r.from; !from.After(r.to); from = from.AddDays(n) {
+		to := from.AddDays(n - 1)
+		if to.After(r.to) {
+			to = r.to
+		}
+		windows = append(windows, dateRange{from, to})
+	}
+	if len(windows) == 0 {
+		return []dateRange{r}
+	}
+	return windows
+}
+
+// mergeDays merges lists of days, dropping duplicated dates
+// and sorting them by date.
+func mergeDays(lists [][]*RealAssetDay) []*RealAssetDay {
+	seen := make(map[Date]bool)
+	var days []*RealAssetDay
+	for _, l := range lists {
+		for _, d := range l {
+			if seen[d.Attributes.Date] {
+				continue
+			}
+			seen[d.Attributes.Date] = true
+			days = append(days, d)
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Attributes.Date.Before(days[j].Attributes.Date)
+	})
+	return days
 }
 
 type ConceptualAssetRealAsset struct {
@@ -198,16 +394,16 @@ type ConceptualAssetRealAssetAttributes struct {
 	Name              string                          `json:"name"`
 	Symbol            string                          `json:"symbol"`
 	Serie             string                          `json:"serie"`
-	StartDate         string                          `json:"start_date"`
-	EndDate           string                          `json:"end_date"`
-	PreviousAssetID   string                          `json:"previous_asset_id"`
+	StartDate         Date                            `json:"start_date"`
+	EndDate           *Date                           `json:"end_date"`          // Nil while the asset is active
+	PreviousAssetID   *int                            `json:"previous_asset_id"` // Nil if the asset replaced no other
 	LastDay           ConceptualAssetRealAssetLastDay `json:"last_day"`
 	ConceptualAssetID int                             `json:"conceptual_asset_id"`
 }
 
 type ConceptualAssetRealAssetLastDay struct {
 	Rate float64 `json:"rate"`
-	Date string  `json:"date"`
+	Date Date    `json:"date"`
 }
 
 // ListByConceptualAsset lists all Real Assets
@@ -215,16 +411,26 @@ type ConceptualAssetRealAssetLastDay struct {
 //
 // Endpoint: GET /conceptual_assets/:id/real_assets
 func (s *RealAssetsService) ListByConceptualAsset(ctx context.Context, id string) ([]*ConceptualAssetRealAsset, error) {
+	d, _, err := s.ListByConceptualAssetWithResponse(ctx, id)
+	return d, err
+}
+
+// ListByConceptualAssetWithResponse is like ListByConceptualAsset, but it also returns
+// the response of the API.
+func (s *RealAssetsService) ListByConceptualAssetWithResponse(ctx context.Context, id string) ([]*ConceptualAssetRealAsset, *Response, error) {
+	ctx, op := s.client.startOperation(ctx, "RealAssets", "ListByConceptualAsset", "/conceptual_assets/:id/real_assets", attr(attrResourceID, id))
+	defer op.end()
+
 	url := fmt.Sprintf("%s/%s%s", s.client.baseURL.String()+conceptualAssetsEndpoint, id, realAssetsEndpoint)
 
 	var d struct {
 		Data []*ConceptualAssetRealAsset `json:"data"`
 	}
 
-	err := s.client.get(ctx, url, &d)
+	resp, err := s.client.get(ctx, url, &d)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
-	return d.Data, nil
+	return d.Data, resp, nil
 }
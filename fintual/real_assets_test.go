@@ -0,0 +1,302 @@
+package fintual
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func mustParseDate(t *testing.T, s string) Date {
+	t.Helper()
+
+	d, err := ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestSplitRange(t *testing.T) {
+	tests := []struct {
+		from, to string
+		n        int
+		want     []string
+	}{
+		{"2020-01-01", "2020-01-10", 0, []string{"2020-01-01/2020-01-10"}},
+		{"2020-01-01", "2020-01-10", 10, []string{"2020-01-01/2020-01-10"}},
+		{"2020-01-01", "2020-01-10", 4, []string{"2020-01-01/2020-01-04", "2020-01-05/2020-01-08", "2020-01-09/2020-01-10"}},
+		{"2020-02-27", "2020-03-02", 2, []string{"2020-02-27/2020-02-28", "2020-02-29/2020-03-01", "2020-03-02/2020-03-02"}},
+		{"2020-01-01", "2020-01-01", 365, []string{"2020-01-01/2020-01-01"}},
+		{"2020-01-10", "2020-01-01", 4, []string{"2020-01-10/2020-01-01"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, w := range splitRange(dateRange{mustParseDate(t, tt.from), mustParseDate(t, tt.to)}, tt.n) {
+			got = append(got, w.from.String()+"/"+w.to.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitRange(%s, %s, %d) = %v, want %v", tt.from, tt.to, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestMergeDays(t *testing.T) {
+	day := func(date string) *RealAssetDay {
+		return &RealAssetDay{Attributes: RealAssetDayAttributes{Date: mustParseDate(t, date)}}
+	}
+
+	got := mergeDays([][]*RealAssetDay{
+		{day("2020-01-03"), day("2020-01-02")},
+		{day("2020-01-01"), day("2020-01-03")},
+		nil,
+		{day("2020-01-04")},
+	})
+
+	var dates []string
+	for _, d := range got {
+		dates = append(dates, d.Attributes.Date.String())
+	}
+	want := []string{"2020-01-01", "2020-01-02", "2020-01-03", "2020-01-04"}
+	if !reflect.DeepEqual(dates, want) {
+		t.Errorf("mergeDays returned %v, want %v", dates, want)
+	}
+}
+
+// daysHandler serves every day of the requested range, plus the day after
+// it, so that consecutive windows return a common day. It records the
+// requested ranges.
+type daysHandler struct {
+	mu     sync.Mutex
+	ranges []string
+	fail   string // from_date of a range to fail with a 500
+}
+
+func (h *daysHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	h.mu.Lock()
+	h.ranges = append(h.ranges, q.Get("from_date")+"/"+q.Get("to_date"))
+	h.mu.Unlock()
+
+	if q.Get("from_date") == h.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	from, err := ParseDate(q.Get("from_date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := ParseDate(q.Get("to_date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var days []string
+	for d := to.AddDays(1); !d.Before(from); d = d.AddDays(-1) {
+		days = append(days, fmt.Sprintf(`{"id":"186","type":"real_asset_day","attributes":{"date":%q,"price":1}}`, d))
+	}
+	fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(days, ","))
+}
+
+func TestListDaysByDates_splitsRangeIntoWindows(t *testing.T) {
+	h := &daysHandler{}
+	c := setup(t, h, WithDaysWindow(10))
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-25")
+	days, err := c.RealAssets.ListDaysByDates(context.Background(), "186", from, to)
+	if err != nil {
+		t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+	}
+
+	sort.Strings(h.ranges)
+	wantRanges := []string{"2020-01-01/2020-01-10", "2020-01-11/2020-01-20", "2020-01-21/2020-01-25"}
+	if !reflect.DeepEqual(h.ranges, wantRanges) {
+		t.Errorf("requested ranges %v, want %v", h.ranges, wantRanges)
+	}
+
+	// The handler serves one day past each window, so the days of the
+	// first two windows are merged, and the last day is past the range.
+	if len(days) != 26 {
+		t.Fatalf("got %d days, want 26", len(days))
+	}
+	for i, d := range days {
+		if want := from.AddDays(i); d.Attributes.Date != want {
+			t.Fatalf("day %d is %s, want %s", i, d.Attributes.Date, want)
+		}
+	}
+}
+
+func TestListDaysByDatesWithResponse_coversEveryWindow(t *testing.T) {
+	var requests int32
+	h := &concurrencyHandler{h: &daysHandler{}}
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}),
+		WithDaysWindow(10),
+		WithBatchConcurrency(1),
+		WithRetryPolicy(&RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, RetryableStatus: []int{http.StatusServiceUnavailable}}),
+	)
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-25")
+	_, resp, err := c.RealAssets.ListDaysByDatesWithResponse(context.Background(), "186", from, to)
+	if err != nil {
+		t.Fatalf("RealAssets.ListDaysByDatesWithResponse returned error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Cached {
+		t.Errorf("got status %d, cached %v, want 200 and not cached", resp.StatusCode, resp.Cached)
+	}
+	// One of the 3 windows is retried once.
+	if resp.Attempts != 4 {
+		t.Errorf("Response.Attempts = %d, want 4", resp.Attempts)
+	}
+	// Windows are requested one at a time, each taking at least 10ms.
+	if resp.Duration < 30*time.Millisecond {
+		t.Errorf("Response.Duration = %v, want at least the 30ms of the 3 windows", resp.Duration)
+	}
+}
+
+func TestListDaysByDates_singleWindow(t *testing.T) {
+	h := &daysHandler{}
+	c := setup(t, h)
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-12-30")
+	if _, err := c.RealAssets.ListDaysByDates(context.Background(), "186", from, to); err != nil {
+		t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+	}
+	if want := []string{"2020-01-01/2020-12-30"}; !reflect.DeepEqual(h.ranges, want) {
+		t.Errorf("requested ranges %v, want %v", h.ranges, want)
+	}
+}
+
+func TestListDaysByDates_failedWindow(t *testing.T) {
+	h := &daysHandler{fail: "2020-01-11"}
+	c := setup(t, h, WithDaysWindow(10))
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-25")
+	days, err := c.RealAssets.ListDaysByDates(context.Background(), "186", from, to)
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusInternalServerError {
+		t.Fatalf("RealAssets.ListDaysByDates returned error %v, want a 500 *Error", err)
+	}
+	if days != nil {
+		t.Errorf("got %d days along with the error, want none", len(days))
+	}
+}
+
+func TestListDaysByDates_invalidDates(t *testing.T) {
+	c := setup(t, &daysHandler{})
+
+	if _, err := c.RealAssets.ListDaysByDates(context.Background(), "186", Date{}, mustParseDate(t, "2020-01-01")); err == nil {
+		t.Error("RealAssets.ListDaysByDates with a zero date returned no error")
+	}
+}
+
+// concurrencyHandler wraps h, delaying every request and recording the
+// maximum number of requests handled at once.
+type concurrencyHandler struct {
+	h             http.Handler
+	inFlight, max int32
+}
+
+func (h *concurrencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := atomic.AddInt32(&h.inFlight, 1)
+	defer atomic.AddInt32(&h.inFlight, -1)
+	for {
+		m := atomic.LoadInt32(&h.max)
+		if n <= m || atomic.CompareAndSwapInt32(&h.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	h.h.ServeHTTP(w, r)
+}
+
+func TestListDaysByDates_boundsConcurrency(t *testing.T) {
+	h := &concurrencyHandler{h: &daysHandler{}}
+	c := setup(t, h, WithDaysWindow(10), WithBatchConcurrency(2))
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-25")
+	if _, err := c.RealAssets.ListDaysByDates(context.Background(), "186", from, to); err != nil {
+		t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+	}
+	if m := atomic.LoadInt32(&h.max); m > 2 {
+		t.Errorf("server handled %d concurrent requests, want at most 2", m)
+	}
+}
+
+func TestListDaysByDatesMany_boundsConcurrency(t *testing.T) {
+	days := &daysHandler{}
+	h := &concurrencyHandler{h: days}
+	c := setup(t, h, WithDaysWindow(10), WithBatchConcurrency(2))
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-25")
+	got, err := c.RealAssets.ListDaysByDatesMany(context.Background(), []string{"1", "2", "3"}, from, to)
+	if err != nil {
+		t.Fatalf("RealAssets.ListDaysByDatesMany returned error: %v", err)
+	}
+	if m := atomic.LoadInt32(&h.max); m > 2 {
+		t.Errorf("server handled %d concurrent requests, want at most 2", m)
+	}
+	if len(days.ranges) != 9 {
+		t.Errorf("server received %d requests, want 3 windows for each of 3 IDs", len(days.ranges))
+	}
+	for id, d := range got {
+		if len(d) != 26 {
+			t.Errorf("got %d days for ID %s, want 26", len(d), id)
+		}
+	}
+}
+
+func TestRealAssetAttributes_nullableFields(t *testing.T) {
+	tests := []struct {
+		json         string
+		wantEndDate  string // Empty for nil
+		wantPrevious int    // Zero for nil
+	}{
+		{`{"end_date":null,"previous_asset_id":null}`, "", 0},
+		{`{}`, "", 0},
+		{`{"end_date":"2021-06-30","previous_asset_id":185}`, "2021-06-30", 185},
+	}
+
+	for _, tt := range tests {
+		check := func(name string, endDate *Date, previous *int) {
+			t.Helper()
+			if (endDate == nil) != (tt.wantEndDate == "") || endDate != nil && endDate.String() != tt.wantEndDate {
+				t.Errorf("%s %s has EndDate %v, want %q", name, tt.json, endDate, tt.wantEndDate)
+			}
+			if (previous == nil) != (tt.wantPrevious == 0) || previous != nil && *previous != tt.wantPrevious {
+				t.Errorf("%s %s has PreviousAssetID %v, want %d", name, tt.json, previous, tt.wantPrevious)
+			}
+		}
+
+		var ra RealAssetAttributes
+		if err := json.Unmarshal([]byte(tt.json), &ra); err != nil {
+			t.Fatalf("decoding RealAssetAttributes %s returned error: %v", tt.json, err)
+		}
+		check("RealAssetAttributes", ra.EndDate, ra.PreviousAssetID)
+
+		var ca ConceptualAssetRealAssetAttributes
+		if err := json.Unmarshal([]byte(tt.json), &ca); err != nil {
+			t.Fatalf("decoding ConceptualAssetRealAssetAttributes %s returned error: %v", tt.json, err)
+		}
+		check("ConceptualAssetRealAssetAttributes", ca.EndDate, ca.PreviousAssetID)
+	}
+}
@@ -0,0 +1,17 @@
+package fintual
+
+import (
+	"net/http"
+	"time"
+)
+
+// Response wraps the HTTP response returned by the Fintual API, along
+// with metadata about the request which produced it. The body of the
+// response has already been read and closed.
+type Response struct {
+	*http.Response
+
+	Duration time.Duration // Time taken by the request, including retries
+	Attempts int           // Number of attempts made for the request
+	Cached   bool          // Whether the response was served from a cache
+}
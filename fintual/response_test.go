@@ -0,0 +1,44 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithResponse_retriedRequest(t *testing.T) {
+	var requests int32
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"1","type":"bank","attributes":{"name":"Nova"}}]}`))
+	}), WithRetryPolicy(DefaultRetryPolicy()))
+
+	banks, resp, err := c.Banks.ListAllWithResponse(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Banks.ListAllWithResponse returned error: %v", err)
+	}
+	if len(banks) != 1 {
+		t.Errorf("got %d banks, want 1", len(banks))
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Request-Id") != "req-1" {
+		t.Errorf("response has status %d and request ID %q, want 200 and req-1", resp.StatusCode, resp.Header.Get("X-Request-Id"))
+	}
+	if resp.Attempts != 2 {
+		t.Errorf("Response.Attempts = %d, want 2", resp.Attempts)
+	}
+	// The Retry-After header sets the backoff before the second attempt.
+	if resp.Duration < time.Second || resp.Duration > 10*time.Second {
+		t.Errorf("Response.Duration = %v, want at least the 1s backoff", resp.Duration)
+	}
+	if resp.Cached {
+		t.Error("Response.Cached = true, want false")
+	}
+}
@@ -0,0 +1,191 @@
+package fintual
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxWait bounds the backoff of a RetryPolicy without MaxBackoff,
+// so that it does not overflow.
+const maxWait = time.Duration(1 << 62)
+
+// RetryPolicy specifies how failed requests are retried by the client.
+//
+// Only GET and HEAD requests are retried, unless RetryNonIdempotent is set.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for a single
+	// request, including the first one. Values lower than 2 disable retries.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the exponential backoff curve. The wait
+	// before attempt n is a random duration between zero and
+	// min(MaxBackoff, MinBackoff * 2^(n-1)) (full jitter). Responses with a
+	// Retry-After header longer than MaxBackoff are not retried.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RetryableStatus lists the HTTP status codes which are retried.
+	RetryableStatus []int
+
+	// RetryableError reports whether a transport error is retried. If nil,
+	// every transport error is retried unless the request context is done.
+	RetryableError func(err error) bool
+
+	// RetryNonIdempotent enables retries for requests other than
+	// GET and HEAD, such as POST and DELETE.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy which makes up to 4 attempts,
+// retrying transport errors and 429, 502, 503 and 504 responses.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  250 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		RetryableStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// allows reports whether req may be retried under the policy.
+func (p *RetryPolicy) allows(req *http.Request) bool {
+	if p == nil || p.MaxAttempts < 2 {
+		return false
+	}
+	if p.RetryNonIdempotent {
+		return req.Body == nil || req.GetBody != nil
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return req.Body == nil || req.GetBody != nil
+	}
+	return false
+}
+
+// shouldRetry reports whether the outcome of an attempt is retryable.
+func (p *RetryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if p.RetryableError != nil {
+			return p.RetryableError(err)
+		}
+		return true
+	}
+
+	for _, code := range p.RetryableStatus {
+		if resp.StatusCode == code {
+			return !p.waitsTooLong(resp)
+		}
+	}
+	return false
+}
+
+// waitsTooLong reports whether resp asks to wait longer than
+// MaxBackoff before retrying, with a Retry-After header.
+func (p *RetryPolicy) waitsTooLong(resp *http.Response) bool {
+	d, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+	return ok && p.MaxBackoff > 0 && d > p.MaxBackoff
+}
+
+// backoff returns how long to wait before the next attempt, given the number
+// of attempts made so far. A Retry-After header in resp takes precedence
+// over the backoff curve.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+
+	ceil := float64(p.MinBackoff) * math.Pow(2, float64(attempt-1))
+	if p.MaxBackoff > 0 && ceil > float64(p.MaxBackoff) {
+		ceil = float64(p.MaxBackoff)
+	}
+	if ceil > float64(maxWait) {
+		ceil = float64(maxWait)
+	}
+	if ceil <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceil) + 1))
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which may be
+// either a number of seconds or an HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		if secs > int(maxWait/time.Second) {
+			return maxWait, true
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	d := time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// sleep waits for d or until ctx is done, whichever happens first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// rewind returns a copy of req which can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// discard drains and closes the body of resp so that the underlying
+// connection can be reused.
+func discard(resp *http.Response) {
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+}
@@ -0,0 +1,148 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"99999999999999", maxWait, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	got, ok := parseRetryAfter(date)
+	if !ok || got < 58*time.Minute || got > time.Hour {
+		t.Errorf("parseRetryAfter(%q) = %v, %v, want about an hour", date, got, ok)
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceil := 100 * time.Millisecond << (attempt - 1)
+		if ceil > time.Second {
+			ceil = time.Second
+		}
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(attempt, nil); d < 0 || d > ceil {
+				t.Fatalf("backoff(%d) = %v, want between 0 and %v", attempt, d, ceil)
+			}
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	if d := p.backoff(1, resp); d != 3*time.Second {
+		t.Errorf("backoff with Retry-After: 3 = %v, want 3s", d)
+	}
+}
+
+func TestRetryPolicy_backoffWithoutMaxBackoff(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 100, MinBackoff: time.Second}
+
+	for attempt := 1; attempt <= 100; attempt++ {
+		if d := p.backoff(attempt, nil); d < 0 || d > maxWait {
+			t.Fatalf("backoff(%d) = %v, want between 0 and %v", attempt, d, maxWait)
+		}
+	}
+}
+
+func TestRetryPolicy_allows(t *testing.T) {
+	body := func() *http.Request {
+		req, _ := http.NewRequest("POST", "https://example.com", strings.NewReader("{}"))
+		return req
+	}
+	withoutGetBody := body()
+	withoutGetBody.GetBody = nil
+
+	tests := []struct {
+		name   string
+		policy *RetryPolicy
+		req    *http.Request
+		want   bool
+	}{
+		{"nil policy", nil, mustRequest(t, "GET"), false},
+		{"single attempt", &RetryPolicy{MaxAttempts: 1}, mustRequest(t, "GET"), false},
+		{"GET", DefaultRetryPolicy(), mustRequest(t, "GET"), true},
+		{"HEAD", DefaultRetryPolicy(), mustRequest(t, "HEAD"), true},
+		{"PUT", DefaultRetryPolicy(), mustRequest(t, "PUT"), false},
+		{"DELETE", DefaultRetryPolicy(), mustRequest(t, "DELETE"), false},
+		{"POST", DefaultRetryPolicy(), body(), false},
+		{"POST opted in", &RetryPolicy{MaxAttempts: 2, RetryNonIdempotent: true}, body(), true},
+		{"DELETE opted in", &RetryPolicy{MaxAttempts: 2, RetryNonIdempotent: true}, mustRequest(t, "DELETE"), true},
+		{"body not rewindable", &RetryPolicy{MaxAttempts: 2, RetryNonIdempotent: true}, withoutGetBody, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.allows(tt.req); got != tt.want {
+			t.Errorf("%s: allows = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_shouldRetry(t *testing.T) {
+	p := DefaultRetryPolicy()
+	status := func(code int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: code, Header: make(http.Header)}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"transport error", context.Background(), nil, errors.New("connection reset"), true},
+		{"context done", canceled, nil, errors.New("connection reset"), false},
+		{"503", context.Background(), status(503, ""), nil, true},
+		{"500", context.Background(), status(500, ""), nil, false},
+		{"404", context.Background(), status(404, ""), nil, false},
+		{"429 with short Retry-After", context.Background(), status(429, "5"), nil, true},
+		{"429 with Retry-After over MaxBackoff", context.Background(), status(429, "3600"), nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := p.shouldRetry(tt.ctx, tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: shouldRetry = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func mustRequest(t *testing.T, method string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(method, "https://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
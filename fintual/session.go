@@ -0,0 +1,102 @@
+package fintual
+
+import "sync"
+
+// Session holds the credentials of an authenticated user.
+type Session struct {
+	Email string `json:"email"` // User's email
+	Token string `json:"token"` // Access token returned by the API
+}
+
+// valid reports whether s holds a complete set of credentials.
+func (s Session) valid() bool {
+	return s.Email != "" && s.Token != ""
+}
+
+// sessionState holds the session of a Client.
+// It is safe for concurrent use.
+type sessionState struct {
+	mu      sync.RWMutex
+	session Session
+	login   *Credentials // Credentials for logging in again if a stored session is rejected
+
+	refreshMu  sync.Mutex
+	refreshing *refreshCall // In-flight re-authentication, if any
+}
+
+// refreshCall is a re-authentication shared by concurrent requests.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func (s *sessionState) load() Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.session
+}
+
+func (s *sessionState) store(session Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = session
+	s.login = nil
+}
+
+// restore sets a session loaded from a TokenStore, keeping the credentials
+// given for it in case the API no longer accepts its token.
+func (s *sessionState) restore(session Session, creds Credentials) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = session
+	s.login = &creds
+}
+
+// canLogin reports whether credentials are kept for logging
+// in again when session is rejected.
+func (s *sessionState) canLogin(session Session) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.login != nil && s.session == session
+}
+
+// takeLogin returns the credentials kept for logging in again when session
+// is rejected, and forgets them so that they are only tried once.
+func (s *sessionState) takeLogin(session Session) (Credentials, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.login == nil || s.session != session {
+		return Credentials{}, false
+	}
+	creds := *s.login
+	s.login = nil
+	return creds, true
+}
+
+// Session returns the current session of the client. The returned
+// session is empty if the client is not authenticated.
+func (c *Client) Session() Session {
+	return c.session.load()
+}
+
+// SetSession atomically replaces the credentials used by the client,
+// e.g. with a session retrieved from a previous call to Client.Session.
+func (c *Client) SetSession(s Session) {
+	c.session.store(s)
+}
+
+// WithSession returns a lightweight copy of the client whose services make
+// authenticated requests on behalf of the user of the given session.
+//
+// The copy shares the HTTP client, rate limiter and the rest of the
+// configuration of c, but has its own credentials: it neither uses the
+// TokenStore nor the CredentialsProvider of c.
+func (c *Client) WithSession(s Session) *Client {
+	clone := *c
+	clone.session = &sessionState{session: s}
+	clone.tokens = nil
+	clone.credentials = nil
+	clone.initServices()
+	return &clone
+}
@@ -0,0 +1,113 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithSession(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		tokens []string
+	)
+	h := &authHandler{token: "parent"}
+	store := NewMemoryTokenStore()
+	limiter := NewRateLimiter(100, 10)
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens = append(tokens, r.Header.Get(userTokenHeader))
+		mu.Unlock()
+		if r.Header.Get(userTokenHeader) == "other" {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), WithRateLimiter(limiter), WithTokenStore(store), WithCredentialsProvider(testCredentials))
+	parent := Session{Email: "user@example.com", Token: "parent"}
+	c.SetSession(parent)
+
+	other := c.WithSession(Session{Email: "other@example.com", Token: "other"})
+	if other.limiter != c.limiter || other.flights != c.flights {
+		t.Error("WithSession copy does not share the rate limiter and flights of its parent")
+	}
+	if _, err := other.Goals.ListAll(context.Background()); err != nil {
+		t.Fatalf("Goals.ListAll of the copy returned error: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0] != "other" {
+		t.Errorf("copy sent tokens %q, want [other]", tokens)
+	}
+
+	rejected := c.WithSession(Session{Email: "other@example.com", Token: "expired"})
+	if _, err := rejected.Goals.ListAll(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Goals.ListAll of a rejected copy returned error %v, want %v", err, ErrUnauthorized)
+	}
+	if n := atomic.LoadInt32(&h.logins); n != 0 {
+		t.Errorf("rejected copy logged in %d times, want 0", n)
+	}
+	if _, err := store.Load(context.Background()); !errors.Is(err, ErrNoSession) {
+		t.Errorf("TokenStore.Load returned error %v, want %v", err, ErrNoSession)
+	}
+
+	if s := c.Session(); s != parent {
+		t.Errorf("parent session is %+v, want %+v", s, parent)
+	}
+	if _, err := c.Goals.ListAll(context.Background()); err != nil {
+		t.Errorf("Goals.ListAll of the parent returned error: %v", err)
+	}
+}
+
+func TestSetSession_concurrentRequests(t *testing.T) {
+	sessions := []Session{
+		{Email: "a@example.com", Token: "token-a"},
+		{Email: "b@example.com", Token: "token-b"},
+	}
+	tokens := map[string]string{sessions[0].Email: sessions[0].Token, sessions[1].Email: sessions[1].Token}
+	var mismatches int32
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokens[r.Header.Get(userEmailHeader)] != r.Header.Get(userTokenHeader) {
+			atomic.AddInt32(&mismatches, 1)
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	c.SetSession(sessions[0])
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				c.SetSession(sessions[i%2])
+			}
+		}
+	}()
+
+	var requests sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			for j := 0; j < 25; j++ {
+				if _, err := c.Goals.ListAll(context.Background()); err != nil {
+					t.Errorf("Goals.ListAll returned error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	requests.Wait()
+	close(done)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&mismatches); n != 0 {
+		t.Errorf("%d requests carried the email of one session and the token of another", n)
+	}
+}
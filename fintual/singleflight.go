@@ -0,0 +1,121 @@
+package fintual
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// flightGroup coalesces identical concurrent GET requests,
+// so that they share a single request to the API.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight // In-flight requests by URL
+}
+
+// flight is a request shared by one or more callers.
+type flight struct {
+	done    chan struct{}
+	waiters int                // Callers waiting for the request
+	cancel  context.CancelFunc // Cancels the request once every caller has left
+	op      *operation         // Records the outcome of the request for every caller
+
+	resp *Response
+	body []byte
+	err  error
+}
+
+// rawGetter makes a GET request, returning the raw body of the response.
+type rawGetter func(req *http.Request) (*Response, []byte, error)
+
+// do makes req with fn, unless an identical request is already in flight,
+// in which case its result is shared. Each caller stops waiting as soon as
+// the context of its own request is done, and the shared request is
+// canceled once no caller waits for it anymore. The outcome of the shared
+// request is recorded on the operation of every caller.
+//
+// The shared request runs with the values of the context of the caller
+// which started it, including its span. That caller's operation span is
+// therefore kept from ending until the request is done, even if the
+// caller leaves earlier.
+func (g *flightGroup) do(req *http.Request, fn rawGetter) (*Response, []byte, error) {
+	ctx := req.Context()
+	key := req.URL.String()
+	op := operationFromContext(ctx)
+
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if !ok {
+		fop := &operation{}
+		if op != nil {
+			fop.Operation = op.Operation
+		}
+		fctx, cancel := context.WithCancel(context.WithValue(detachedContext{ctx}, operationKey{}, fop))
+		f = &flight{done: make(chan struct{}), cancel: cancel, op: fop}
+		g.flights[key] = f
+		if op != nil {
+			op.hold()
+		}
+
+		go func() {
+			f.resp, f.body, f.err = fn(req.WithContext(fctx))
+			if op != nil {
+				op.release()
+			}
+			g.forget(key, f)
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		var resp *Response
+		if f.resp != nil {
+			r := *f.resp
+			resp = &r
+		}
+		if op != nil {
+			op.record(resp, f.op.attempts, f.err)
+		}
+		return resp, f.body, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		if op != nil {
+			op.record(nil, 0, ctx.Err())
+		}
+		return nil, nil, ctx.Err()
+	}
+}
+
+// forget removes f from the in-flight requests.
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
+
+// detachedContext carries the values of its parent context,
+// but is never canceled and has no deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
@@ -0,0 +1,219 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingHandler serves real assets once release is closed,
+// counting the requests it receives.
+type blockingHandler struct {
+	requests int32
+	release  chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{release: make(chan struct{})}
+}
+
+func (h *blockingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&h.requests, 1)
+	select {
+	case <-h.release:
+	case <-r.Context().Done():
+		return
+	}
+	w.Write([]byte(`{"data":{"id":"186","type":"real_asset","attributes":{"name":"Risky Norris"}}}`))
+}
+
+// waitForWaiters waits until n callers wait for the in-flight request to url.
+func waitForWaiters(t *testing.T, c *Client, url string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.flights.mu.Lock()
+		f := c.flights.flights[url]
+		joined := f != nil && f.waiters == n
+		c.flights.mu.Unlock()
+		if joined {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d callers never waited for %s", n, url)
+}
+
+func TestGet_coalescesConcurrentRequests(t *testing.T) {
+	h := newBlockingHandler()
+	c := setup(t, h)
+	url := c.baseURL.String() + realAssetsEndpoint + "/186"
+
+	const callers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ra, err := c.RealAssets.Get(context.Background(), "186")
+			if err == nil && ra.Attributes.Name != "Risky Norris" {
+				err = errors.New("unexpected real asset " + ra.Attributes.Name)
+			}
+			errs <- err
+		}()
+	}
+
+	waitForWaiters(t, c, url, callers)
+	close(h.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("RealAssets.Get returned error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&h.requests); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
+
+func TestGet_cancelsWaitersIndependently(t *testing.T) {
+	h := newBlockingHandler()
+	c := setup(t, h)
+	url := c.baseURL.String() + realAssetsEndpoint + "/186"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := c.RealAssets.Get(ctx, "186")
+		canceled <- err
+	}()
+	waitForWaiters(t, c, url, 1)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.RealAssets.Get(context.Background(), "186")
+		done <- err
+	}()
+	waitForWaiters(t, c, url, 2)
+
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller got error %v, want %v", err, context.Canceled)
+	}
+
+	close(h.release)
+	if err := <-done; err != nil {
+		t.Errorf("remaining caller got error: %v", err)
+	}
+	if n := atomic.LoadInt32(&h.requests); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
+
+func TestGet_cancelsSharedRequestOnceEveryWaiterLeaves(t *testing.T) {
+	h := newBlockingHandler()
+	defer close(h.release)
+	c := setup(t, h)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.RealAssets.Get(ctx, "186"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RealAssets.Get returned error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	c.flights.mu.Lock()
+	n := len(c.flights.flights)
+	c.flights.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d requests still in flight, want 0", n)
+	}
+}
+
+func TestGet_recordsSharedOutcomeOnEveryOperation(t *testing.T) {
+	h := newBlockingHandler()
+	tracer := &testTracer{}
+	c := setup(t, h, WithTracer(tracer))
+	url := c.baseURL.String() + realAssetsEndpoint + "/186"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.RealAssets.Get(context.Background(), "186")
+		}()
+	}
+	waitForWaiters(t, c, url, 2)
+	close(h.release)
+	wg.Wait()
+
+	spans := tracer.named("RealAssets.Get")
+	if len(spans) != 2 {
+		t.Fatalf("got %d RealAssets.Get spans, want 2", len(spans))
+	}
+	for _, s := range spans {
+		if s.ended == 0 || s.err != nil {
+			t.Errorf("span ended = %v with error %v, want ended without error", s.ended != 0, s.err)
+		}
+		if got := s.attrs[attrStatusCode]; got != http.StatusOK {
+			t.Errorf("span status code is %v, want %d", got, http.StatusOK)
+		}
+	}
+}
+
+func TestGet_tracesSharedRequestUnderFirstCaller(t *testing.T) {
+	h := newBlockingHandler()
+	tracer := &testTracer{}
+	c := setup(t, h, WithTracer(tracer))
+	url := c.baseURL.String() + realAssetsEndpoint + "/186"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := c.RealAssets.Get(ctx, "186")
+		canceled <- err
+	}()
+	waitForWaiters(t, c, url, 1)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.RealAssets.Get(context.Background(), "186")
+		done <- err
+	}()
+	waitForWaiters(t, c, url, 2)
+
+	// The first caller leaves while the shared request keeps running.
+	cancel()
+	<-canceled
+	close(h.release)
+	if err := <-done; err != nil {
+		t.Fatalf("remaining caller got error: %v", err)
+	}
+
+	ops, attempts := tracer.named("RealAssets.Get"), tracer.named("HTTP GET")
+	if len(ops) != 2 || len(attempts) != 1 {
+		t.Fatalf("got %d operation and %d attempt spans, want 2 and 1", len(ops), len(attempts))
+	}
+	first, second, attempt := ops[0], ops[1], attempts[0]
+	if attempt.parent != first.id {
+		t.Errorf("attempt span has parent %d, want the span %d of the first caller", attempt.parent, first.id)
+	}
+	if attempt.ended == 0 || first.ended < attempt.ended {
+		t.Errorf("span of the first caller ended at %d, before its attempt span ended at %d", first.ended, attempt.ended)
+	}
+	if !errors.Is(first.err, context.Canceled) {
+		t.Errorf("span of the first caller ended with error %v, want %v", first.err, context.Canceled)
+	}
+	if second.ended == 0 || second.err != nil || second.attrs[attrStatusCode] != http.StatusOK {
+		t.Errorf("span of the second caller ended %v with error %v and status %v, want ended without error and 200",
+			second.ended != 0, second.err, second.attrs[attrStatusCode])
+	}
+}
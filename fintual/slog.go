@@ -0,0 +1,38 @@
+//go:build go1.21
+
+package fintual
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// SlogLogger returns a Logger which writes to l. Successful attempts are
+// logged at debug level and failed ones at error level.
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+func (s slogLogger) LogRequest(ctx context.Context, r RequestLog) {
+	attrs := []slog.Attr{
+		slog.String("service", r.Operation.Service),
+		slog.String("operation", r.Operation.String()),
+		slog.String("method", r.Method),
+		slog.String("url", r.URL),
+		slog.Int("attempt", r.Attempt),
+		slog.Duration("duration", r.Duration),
+	}
+
+	if r.Err != nil {
+		attrs = append(attrs, slog.Any("error", r.Err))
+		s.l.LogAttrs(ctx, slog.LevelError, "fintual request failed", attrs...)
+		return
+	}
+
+	attrs = append(attrs, slog.Int("status", r.Status), slog.Int64("bytes", r.Bytes))
+	s.l.LogAttrs(ctx, slog.LevelDebug, "fintual request", attrs...)
+}
@@ -0,0 +1,120 @@
+package fintual
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bodyDecoder is implemented by values which decode a response
+// body by themselves, instead of it being unmarshalled at once.
+type bodyDecoder interface {
+	decode(r io.Reader) error
+}
+
+// StreamDays calls fn with every Real Asset Day from and to the given dates,
+// as they are decoded from the response, so that the whole list is never
+// held in memory. Long ranges are requested one window at a time, see
+// WithDaysWindow, in date order.
+//
+// Streaming stops at the first error returned by fn, which StreamDays
+// returns, or once ctx is done. Responses are never cached nor shared
+// with concurrent requests.
+//
+// Endpoint: GET /real_assets/:id/days
+func (s *RealAssetsService) StreamDays(ctx context.Context, id string, from, to Date, fn func(*RealAssetDay) error) error {
+	ctx, op := s.client.startOperation(ctx, "RealAssets", "StreamDays", "/real_assets/:id/days", attr(attrResourceID, id), attr(attrFromDate, from.String()), attr(attrToDate, to.String()))
+	defer op.end()
+
+	if !from.IsValid() || !to.IsValid() {
+		return errors.New("received malformatted or zero value dates")
+	}
+
+	for _, w := range splitRange(dateRange{from, to}, s.client.daysWindow) {
+		url := fmt.Sprintf("%s/%s%s?from_date=%s&to_date=%s", s.client.baseURL.String()+realAssetsEndpoint, id, daysEndpoint, w.from, w.to)
+
+		req, err := s.client.newRequest(ctx, "GET", url, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := s.client.send(req, &dayStream{ctx: ctx, fn: fn}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dayStream decodes the data array of a days response
+// one day at a time, passing each of them to fn.
+type dayStream struct {
+	ctx context.Context
+	fn  func(*RealAssetDay) error
+}
+
+func (s *dayStream) decode(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key != "data" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.decodeData(dec); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+// decodeData decodes the value of the data member, which is either null
+// or an array of days.
+func (s *dayStream) decodeData(dec *json.Decoder) error {
+	t, err := dec.Token()
+	if err != nil || t == nil {
+		return err
+	}
+	if t != json.Delim('[') {
+		return fmt.Errorf("fintual: unexpected %v in days data", t)
+	}
+
+	for dec.More() {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+
+		var d RealAssetDay
+		if err := dec.Decode(&d); err != nil {
+			return err
+		}
+		if err := s.fn(&d); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token of dec, which must be delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("fintual: expected %v in response body, got %v", delim, t)
+	}
+	return nil
+}
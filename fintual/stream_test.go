@@ -0,0 +1,126 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const (
+	streamDay1 = `{"id":"186","type":"real_asset_day","attributes":{"date":"2020-01-01","price":1}}`
+	streamDay2 = `{"id":"186","type":"real_asset_day","attributes":{"date":"2020-01-02","price":2}}`
+)
+
+// bodyHandler serves body to every request.
+func bodyHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+// streamDates streams the days from 2020-01-01 to 2020-01-02 from c
+// and returns their dates.
+func streamDates(t *testing.T, ctx context.Context, c *Client, fn func(*RealAssetDay) error) ([]string, error) {
+	t.Helper()
+
+	var dates []string
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-02")
+	err := c.RealAssets.StreamDays(ctx, "186", from, to, func(d *RealAssetDay) error {
+		dates = append(dates, d.Attributes.Date.String())
+		if fn != nil {
+			return fn(d)
+		}
+		return nil
+	})
+	return dates, err
+}
+
+func TestStreamDays_decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{"only data", `{"data":[` + streamDay1 + `,` + streamDay2 + `]}`, []string{"2020-01-01", "2020-01-02"}, false},
+		{"extra keys", `{"meta":{"pages":[1,{"a":"]"}]},"data":[` + streamDay1 + `,` + streamDay2 + `],"links":{"next":null}}`, []string{"2020-01-01", "2020-01-02"}, false},
+		{"null data", `{"meta":1,"data":null}`, nil, false},
+		{"no data", `{}`, nil, false},
+		{"array body", `[` + streamDay1 + `]`, nil, true},
+		{"string body", `"days"`, nil, true},
+		{"object data", `{"data":{}}`, nil, true},
+		{"truncated", `{"data":[` + streamDay1 + `,`, []string{"2020-01-01"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := setup(t, bodyHandler(tt.body))
+
+			got, err := streamDates(t, context.Background(), c, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StreamDays returned error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StreamDays streamed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamDays_stopsOnCallbackError(t *testing.T) {
+	c := setup(t, bodyHandler(`{"data":[`+streamDay1+`,`+streamDay2+`]}`))
+	errStop := errors.New("stop")
+
+	got, err := streamDates(t, context.Background(), c, func(*RealAssetDay) error {
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("StreamDays returned error %v, want %v", err, errStop)
+	}
+	if len(got) != 1 {
+		t.Errorf("StreamDays streamed %v, want only the first day", got)
+	}
+}
+
+func TestStreamDays_stopsOnCanceledContext(t *testing.T) {
+	c := setup(t, bodyHandler(`{"data":[`+streamDay1+`,`+streamDay2+`]}`))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got, err := streamDates(t, ctx, c, func(*RealAssetDay) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("StreamDays returned error %v, want %v", err, context.Canceled)
+	}
+	if len(got) != 1 {
+		t.Errorf("StreamDays streamed %v, want only the first day", got)
+	}
+}
+
+func TestStreamDays_requestsWindowsInDateOrder(t *testing.T) {
+	h := &daysHandler{}
+	c := setup(t, h, WithDaysWindow(10))
+
+	var n int
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-25")
+	err := c.RealAssets.StreamDays(context.Background(), "186", from, to, func(*RealAssetDay) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RealAssets.StreamDays returned error: %v", err)
+	}
+
+	want := []string{"2020-01-01/2020-01-10", "2020-01-11/2020-01-20", "2020-01-21/2020-01-25"}
+	if !reflect.DeepEqual(h.ranges, want) {
+		t.Errorf("requested ranges %v, want %v", h.ranges, want)
+	}
+	// The handler serves one day past each window.
+	if n != 28 {
+		t.Errorf("streamed %d days, want 28", n)
+	}
+}
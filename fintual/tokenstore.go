@@ -0,0 +1,140 @@
+package fintual
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNoSession is returned by TokenStore.Load when no session is stored.
+var ErrNoSession = errors.New("fintual: no stored session")
+
+// TokenStore persists the session of a Client, so that it
+// survives process restarts. Implementations must be safe
+// for concurrent use.
+type TokenStore interface {
+	// Load returns the stored session, or ErrNoSession if there is none.
+	Load(ctx context.Context) (Session, error)
+	// Save stores the given session, replacing any previous one.
+	Save(ctx context.Context, s Session) error
+	// Clear removes the stored session.
+	Clear(ctx context.Context) error
+}
+
+// MemoryTokenStore is a TokenStore which keeps the session in memory.
+type MemoryTokenStore struct {
+	mu      sync.Mutex
+	session Session
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (m *MemoryTokenStore) Load(ctx context.Context) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.session.valid() {
+		return Session{}, ErrNoSession
+	}
+	return m.session, nil
+}
+
+func (m *MemoryTokenStore) Save(ctx context.Context, s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.session = s
+	return nil
+}
+
+func (m *MemoryTokenStore) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.session = Session{}
+	return nil
+}
+
+// FileTokenStore is a TokenStore which keeps the session
+// in a JSON file only readable by the current user.
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileTokenStore returns a FileTokenStore which keeps
+// the session in the file at path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (f *FileTokenStore) Load(ctx context.Context) (Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, err := ioutil.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Session{}, ErrNoSession
+	}
+	if err != nil {
+		return Session{}, err
+	}
+
+	var s Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Session{}, err
+	}
+	if !s.valid() {
+		return Session{}, ErrNoSession
+	}
+	return s, nil
+}
+
+// Save writes the session to a temporary file which then replaces
+// the store's file, so that a crash never leaves a partial session behind.
+func (f *FileTokenStore) Save(ctx context.Context, s Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *FileTokenStore) Clear(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := os.Remove(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package fintual
+
+import "context"
+
+// Attribute keys set on the spans started by the client.
+const (
+	attrResourceID  = "fintual.resource.id"
+	attrDate        = "fintual.date"
+	attrFromDate    = "fintual.from_date"
+	attrToDate      = "fintual.to_date"
+	attrMethod      = "http.request.method"
+	attrURL         = "url.full"
+	attrStatusCode  = "http.response.status_code"
+	attrResendCount = "http.request.resend_count"
+)
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer starts spans around the calls made by a client. The client starts
+// a span for every service method call, e.g. "RealAssets.ListDaysByDates",
+// and a child span for every HTTP attempt made by it. Spans are propagated
+// through the context, which is the one given to the service method, so
+// they can be linked to the caller's trace.
+//
+// Identical concurrent GET requests share a single request to the API,
+// whose attempt spans are children of the span of the call which started
+// it. That span does not end before the shared request, even if the call
+// returns earlier, e.g. because its context was canceled. The spans of the
+// other calls get the outcome of the shared request, but no attempt spans.
+//
+// Tracer can be implemented on top of OpenTelemetry or any other
+// tracing library.
+type Tracer interface {
+	// Start starts a span with the given name and attributes, and returns
+	// a copy of ctx carrying it.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...Attribute)
+	// End ends the span, which failed if err is not nil.
+	End(err error)
+}
+
+// attr returns an Attribute with the given key and value.
+func attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
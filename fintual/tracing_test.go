@@ -0,0 +1,118 @@
+package fintual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithTracer_spansOperationAndAttempts(t *testing.T) {
+	var requests int32
+	days := &daysHandler{}
+	tracer := &testTracer{}
+	c := setup(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		days.ServeHTTP(w, r)
+	}),
+		WithTracer(tracer),
+		WithRetryPolicy(&RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, RetryableStatus: []int{http.StatusServiceUnavailable}}),
+	)
+
+	from, to := mustParseDate(t, "2020-01-01"), mustParseDate(t, "2020-01-10")
+	if _, err := c.RealAssets.ListDaysByDates(context.Background(), "186", from, to); err != nil {
+		t.Fatalf("RealAssets.ListDaysByDates returned error: %v", err)
+	}
+
+	ops := tracer.named("RealAssets.ListDaysByDates")
+	if len(ops) != 1 {
+		t.Fatalf("got %d RealAssets.ListDaysByDates spans, want 1", len(ops))
+	}
+	op := ops[0]
+	wantAttrs := map[string]interface{}{
+		attrResourceID:  "186",
+		attrFromDate:    "2020-01-01",
+		attrToDate:      "2020-01-10",
+		attrStatusCode:  http.StatusOK,
+		attrResendCount: 1,
+	}
+	for k, want := range wantAttrs {
+		if got := op.attrs[k]; got != want {
+			t.Errorf("operation span has %s = %v, want %v", k, got, want)
+		}
+	}
+	if op.parent != 0 || op.ended == 0 || op.err != nil {
+		t.Errorf("operation span has parent %d, ended %v with error %v, want a root span ended without error", op.parent, op.ended != 0, op.err)
+	}
+
+	attempts := tracer.named("HTTP GET")
+	if len(attempts) != 2 {
+		t.Fatalf("got %d HTTP GET spans, want 2", len(attempts))
+	}
+	for i, s := range attempts {
+		if s.parent != op.id {
+			t.Errorf("attempt %d span has parent %d, want the operation span %d", i, s.parent, op.id)
+		}
+		if s.ended == 0 || s.ended > op.ended || s.err != nil {
+			t.Errorf("attempt %d span ended %v with error %v, want ended before its parent without error", i, s.ended != 0, s.err)
+		}
+		if got := s.attrs[attrResendCount]; got != i {
+			t.Errorf("attempt %d span has %s = %v, want %d", i, attrResendCount, got, i)
+		}
+		if got := s.attrs[attrMethod]; got != "GET" {
+			t.Errorf("attempt %d span has %s = %v, want GET", i, attrMethod, got)
+		}
+	}
+	if attempts[0].attrs[attrStatusCode] != http.StatusServiceUnavailable || attempts[1].attrs[attrStatusCode] != http.StatusOK {
+		t.Errorf("attempt spans have status codes %v and %v, want 503 and 200",
+			attempts[0].attrs[attrStatusCode], attempts[1].attrs[attrStatusCode])
+	}
+}
+
+func TestWithTracer_endsSpansWithError(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		opts           []Option
+		wantAttemptErr bool
+		wantStatus     interface{}
+	}{
+		{"API error", http.NotFoundHandler(), nil, false, http.StatusNotFound},
+		{"transport error", http.NotFoundHandler(), []Option{WithBaseURL(closed.URL)}, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &testTracer{}
+			c := setup(t, tt.handler, append([]Option{WithTracer(tracer)}, tt.opts...)...)
+
+			_, err := c.RealAssets.Get(context.Background(), "186")
+			if err == nil {
+				t.Fatal("RealAssets.Get returned no error")
+			}
+
+			ops, attempts := tracer.named("RealAssets.Get"), tracer.named("HTTP GET")
+			if len(ops) != 1 || len(attempts) != 1 {
+				t.Fatalf("got %d operation and %d attempt spans, want 1 of each", len(ops), len(attempts))
+			}
+			if !errors.Is(ops[0].err, err) {
+				t.Errorf("operation span ended with error %v, want %v", ops[0].err, err)
+			}
+			if (attempts[0].err != nil) != tt.wantAttemptErr {
+				t.Errorf("attempt span ended with error %v, want error %v", attempts[0].err, tt.wantAttemptErr)
+			}
+			if got := attempts[0].attrs[attrStatusCode]; got != tt.wantStatus {
+				t.Errorf("attempt span has %s = %v, want %v", attrStatusCode, got, tt.wantStatus)
+			}
+		})
+	}
+}